@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/hex"
+	"math"
+)
+
+// --- Color Helpers ---
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func rgbToHSL(r, g, b int) (float64, float64, float64) {
+	rf, gf, bf := float64(r)/255.0, float64(g)/255.0, float64(b)/255.0
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	h, s, l := 0.0, 0.0, (max+min)/2.0
+
+	if max != min {
+		d := max - min
+		if l > 0.5 {
+			s = d / (2.0 - max - min)
+		} else {
+			s = d / (max + min)
+		}
+		switch max {
+		case rf:
+			h = (gf - bf) / d
+			if gf < bf {
+				h += 6.0
+			}
+		case gf:
+			h = (bf-rf)/d + 2.0
+		case bf:
+			h = (rf-gf)/d + 4.0
+		}
+		h *= 60.0
+	}
+	return h, s, l
+}
+
+func rgbToHSV(r, g, b int) (float64, float64, float64) {
+	rf, gf, bf := float64(r)/255.0, float64(g)/255.0, float64(b)/255.0
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	h, s, v := 0.0, 0.0, max
+	d := max - min
+	if max != 0 {
+		s = d / max
+	}
+
+	if max != min {
+		switch max {
+		case rf:
+			h = (gf - bf) / d
+			if gf < bf {
+				h += 6.0
+			}
+		case gf:
+			h = (bf-rf)/d + 2.0
+		case bf:
+			h = (rf-gf)/d + 4.0
+		}
+		h *= 60.0
+	}
+	return h, s, v
+}
+
+func rgbToCMYK(r, g, b int) (float64, float64, float64, float64) {
+	if r == 0 && g == 0 && b == 0 {
+		return 0, 0, 0, 1
+	}
+	rf, gf, bf := float64(r)/255.0, float64(g)/255.0, float64(b)/255.0
+	k := 1.0 - math.Max(rf, math.Max(gf, bf))
+	c := (1.0 - rf - k) / (1.0 - k)
+	m := (1.0 - gf - k) / (1.0 - k)
+	y := (1.0 - bf - k) / (1.0 - k)
+	return c, m, y, k
+}
+
+func rgbToHWB(r, g, b int) (float64, float64, float64) {
+	h, _, _ := rgbToHSL(r, g, b)
+	rf, gf, bf := float64(r)/255.0, float64(g)/255.0, float64(b)/255.0
+	w := math.Min(rf, math.Min(gf, bf))
+	bl := 1.0 - math.Max(rf, math.Max(gf, bf))
+	return h, w * 100, bl * 100
+}
+
+// sRGB to linear RGB
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func rgbToXYZ(r, g, b int) (float64, float64, float64) {
+	rf := srgbToLinear(float64(r) / 255.0)
+	gf := srgbToLinear(float64(g) / 255.0)
+	bf := srgbToLinear(float64(b) / 255.0)
+
+	// sRGB D65 matrix
+	x := rf*0.4124564 + gf*0.3575761 + bf*0.1804375
+	y := rf*0.2126729 + gf*0.7151522 + bf*0.0721750
+	z := rf*0.0193339 + gf*0.1191920 + bf*0.9503041
+	return x * 100, y * 100, z * 100
+}
+
+func rgbToLAB(r, g, b int) (float64, float64, float64) {
+	x, y, z := rgbToXYZ(r, g, b)
+	// D65 reference white
+	x /= 95.047
+	y /= 100.0
+	z /= 108.883
+
+	f := func(t float64) float64 {
+		if t > 0.008856 {
+			return math.Pow(t, 1.0/3.0)
+		}
+		return (7.787 * t) + (16.0 / 116.0)
+	}
+
+	fx, fy, fz := f(x), f(y), f(z)
+	L := (116.0 * fy) - 16.0
+	a := 500.0 * (fx - fy)
+	bVal := 200.0 * (fy - fz)
+	return L, a, bVal
+}
+
+func rgbToLCH(r, g, b int) (float64, float64, float64) {
+	L, a, bVal := rgbToLAB(r, g, b)
+	C := math.Sqrt(a*a + bVal*bVal)
+	H := math.Atan2(bVal, a) * (180.0 / math.Pi)
+	if H < 0 {
+		H += 360
+	}
+	return L, C, H
+}
+
+func rgbToOklab(r, g, b int) (float64, float64, float64) {
+	rf := srgbToLinear(float64(r) / 255.0)
+	gf := srgbToLinear(float64(g) / 255.0)
+	bf := srgbToLinear(float64(b) / 255.0)
+
+	l := 0.4122214708*rf + 0.5363325363*gf + 0.0514459929*bf
+	m := 0.2119034982*rf + 0.6806995451*gf + 0.1073969566*bf
+	s := 0.0883024619*rf + 0.2817188376*gf + 0.6299787005*bf
+
+	l_ := math.Cbrt(l)
+	m_ := math.Cbrt(m)
+	s_ := math.Cbrt(s)
+
+	L := 0.2104542553*l_ + 0.7936177850*m_ - 0.0040720468*s_
+	A := 1.9779984951*l_ - 2.4285922050*m_ + 0.4505937099*s_
+	B := 0.0259040371*l_ + 0.7827717662*m_ - 0.8086757660*s_
+	return L, A, B
+}
+
+func rgbToOklch(r, g, b int) (float64, float64, float64) {
+	L, a, bVal := rgbToOklab(r, g, b)
+	C := math.Sqrt(a*a + bVal*bVal)
+	H := math.Atan2(bVal, a) * (180.0 / math.Pi)
+	if H < 0 {
+		H += 360
+	}
+	return L, C, H
+}
+
+// --- Reverse Conversions (to RGB) ---
+
+func hslToRGB(h, s, l float64) (int, int, int) {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return clampInt((r + m) * 255), clampInt((g + m) * 255), clampInt((b + m) * 255)
+}
+
+func hwbToRGB(h, w, bl float64) (int, int, int) {
+	// Normalize whiteness and blackness
+	if w+bl >= 1 {
+		gray := w / (w + bl)
+		g := clampInt(gray * 255)
+		return g, g, g
+	}
+
+	r, g, b := hslToRGB(h, 1.0, 0.5)
+	rf := float64(r)/255*(1-w-bl) + w
+	gf := float64(g)/255*(1-w-bl) + w
+	bf := float64(b)/255*(1-w-bl) + w
+
+	return clampInt(rf * 255), clampInt(gf * 255), clampInt(bf * 255)
+}
+
+func linearToSrgb(c float64) float64 {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return 1.055*math.Pow(c, 1.0/2.4) - 0.055
+}
+
+func xyzToRGB(x, y, z float64) (int, int, int) {
+	// XYZ to linear sRGB
+	x /= 100
+	y /= 100
+	z /= 100
+
+	r := x*3.2404542 + y*-1.5371385 + z*-0.4985314
+	g := x*-0.9692660 + y*1.8760108 + z*0.0415560
+	b := x*0.0556434 + y*-0.2040259 + z*1.0572252
+
+	// Linear to sRGB
+	r = linearToSrgb(r)
+	g = linearToSrgb(g)
+	b = linearToSrgb(b)
+
+	return clampInt(r * 255), clampInt(g * 255), clampInt(b * 255)
+}
+
+func labToRGB(L, A, B float64) (int, int, int) {
+	// LAB to XYZ
+	fy := (L + 16) / 116
+	fx := A/500 + fy
+	fz := fy - B/200
+
+	fInv := func(t float64) float64 {
+		if t > 0.206893 {
+			return t * t * t
+		}
+		return (t - 16.0/116.0) / 7.787
+	}
+
+	x := fInv(fx) * 95.047
+	y := fInv(fy) * 100.0
+	z := fInv(fz) * 108.883
+
+	return xyzToRGB(x, y, z)
+}
+
+func lchToRGB(L, C, H float64) (int, int, int) {
+	hRad := H * math.Pi / 180
+	a := C * math.Cos(hRad)
+	b := C * math.Sin(hRad)
+	return labToRGB(L, a, b)
+}
+
+func oklabToRGB(L, A, B float64) (int, int, int) {
+	l_ := L + 0.3963377774*A + 0.2158037573*B
+	m_ := L - 0.1055613458*A - 0.0638541728*B
+	s_ := L - 0.0894841775*A - 1.2914855480*B
+
+	l := l_ * l_ * l_
+	m := m_ * m_ * m_
+	s := s_ * s_ * s_
+
+	r := +4.0767416621*l - 3.3077115913*m + 0.2309699292*s
+	g := -1.2684380046*l + 2.6097574011*m - 0.3413193965*s
+	b := -0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+
+	r = linearToSrgb(r)
+	g = linearToSrgb(g)
+	b = linearToSrgb(b)
+
+	return clampInt(r * 255), clampInt(g * 255), clampInt(b * 255)
+}
+
+func oklchToRGB(L, C, H float64) (int, int, int) {
+	hRad := H * math.Pi / 180
+	a := C * math.Cos(hRad)
+	b := C * math.Sin(hRad)
+	return oklabToRGB(L, a, b)
+}
+
+func clampInt(v float64) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return int(math.Round(v))
+}
+
+func round(x float64) int {
+	return int(math.Round(x))
+}
+
+func roundDig(x float64, n int) float64 {
+	p := math.Pow(10, float64(n))
+	return math.Round(x*p) / p
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// --- Argument Completion ---
+//
+// completion/complete suggests values for prompt arguments, resource
+// template variables and, as an extension, tool arguments (ref type
+// "ref/tool"), so clients stop guessing unit spellings. Candidates are
+// ranked by rankCompletions.
+
+// maxCompletionValues is the most values one response may carry.
+const maxCompletionValues = 100
+
+type CompleteParams struct {
+	Ref      CompletionRef      `json:"ref"`
+	Argument CompletionArgument `json:"argument"`
+}
+
+type CompletionRef struct {
+	Type string `json:"type"` // ref/prompt, ref/resource or ref/tool
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+type CompletionArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CompletionResult struct {
+	Values  []string `json:"values"`
+	Total   int      `json:"total"`
+	HasMore bool     `json:"hasMore"`
+}
+
+// complete returns ranked suggestions for the argument. Unknown refs and
+// arguments simply have no suggestions.
+func complete(params CompleteParams) CompletionResult {
+	values := rankCompletions(params.Argument.Value, uniqueCandidates(completionCandidates(params.Ref, params.Argument.Name)))
+	res := CompletionResult{Values: values, Total: len(values)}
+	if len(values) > maxCompletionValues {
+		res.Values = values[:maxCompletionValues]
+		res.HasMore = true
+	}
+	return res
+}
+
+// completionCandidates lists every value that argument accepts under ref.
+func completionCandidates(ref CompletionRef, argument string) []string {
+	switch ref.Type {
+	case "ref/tool":
+		switch ref.Name + "." + argument {
+		case "convert.unit":
+			return append(append(append(append(unitNames(), dimensionalSymbols()...), currencyCodes...), commonTimeZones...), "duration")
+		case "convert.to":
+			return append(append(unitNames(), dimensionalSymbols()...), currencyCodes...)
+		case "convert.locale":
+			return dateLocaleNames
+		case "compare.unit_a", "compare.unit_b":
+			return append(unitNames(), dimensionalSymbols()...)
+		case "generate_mock_data.data_type":
+			return mockDataTypes
+		case "analyze_color.color_input":
+			return colorKeywords()
+		}
+	case "ref/prompt":
+		switch ref.Name + "." + argument {
+		case "audit_palette_contrast.colors":
+			return colorKeywords()
+		case "audit_palette_contrast.level":
+			return []string{"AA", "AAA"}
+		case "normalize_measurements.target_unit":
+			return unitNames()
+		}
+	case "ref/resource":
+		switch ref.URI + "#" + argument {
+		case "omni://units/{category}#category":
+			var cats []string
+			for _, c := range unitCategories {
+				cats = append(cats, c.Name)
+			}
+			return cats
+		case "omni://colors/palettes/{name}#name":
+			return paletteNames()
+		}
+	}
+	return nil
+}
+
+// uniqueCandidates drops repeats, such as a unit name that is also a
+// dimensional symbol, keeping the first of each.
+func uniqueCandidates(candidates []string) []string {
+	seen := make(map[string]bool, len(candidates))
+	out := candidates[:0:0]
+	for _, c := range candidates {
+		if !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// unitNames lists every unit spelling inferCategory accepts, once each.
+func unitNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, c := range unitCategories {
+		for _, u := range categorySpellings(c.Name) {
+			if !seen[u] {
+				seen[u] = true
+				names = append(names, u)
+			}
+		}
+	}
+	return names
+}
+
+func colorKeywords() []string {
+	names := make([]string, 0, len(namedColors))
+	for name := range namedColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rankCompletions filters candidates against the typed value and orders
+// them: exact matches, then prefix matches (of the whole candidate or of a
+// path segment, so "tok" finds Asia/Tokyo), then substrings, then fuzzy
+// matches within a small levenshtein distance. An empty value keeps every
+// candidate in its original order.
+func rankCompletions(value string, candidates []string) []string {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if v == "" {
+		return candidates
+	}
+	// Typos are only forgiven once there is enough input to judge them
+	maxDist := len(v) / 3
+
+	type scored struct {
+		value      string
+		tier, dist int
+	}
+	var matches []scored
+	for _, c := range candidates {
+		lc := strings.ToLower(c)
+		switch {
+		case lc == v:
+			matches = append(matches, scored{c, 0, 0})
+		case hasSegmentPrefix(lc, v):
+			matches = append(matches, scored{c, 1, 0})
+		case strings.Contains(lc, v):
+			matches = append(matches, scored{c, 2, 0})
+		default:
+			d := levenshtein(v, lc)
+			if len(lc) > len(v) {
+				// Compare against the start of long candidates so an
+				// unfinished word still matches
+				d = min(d, levenshtein(v, lc[:len(v)]))
+			}
+			if d <= maxDist {
+				matches = append(matches, scored{c, 3, d})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.tier != b.tier {
+			return a.tier < b.tier
+		}
+		if a.dist != b.dist {
+			return a.dist < b.dist
+		}
+		return len(a.value) < len(b.value)
+	})
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.value
+	}
+	return out
+}
+
+// hasSegmentPrefix reports whether prefix starts s or any part of s after
+// a '/' or '_'.
+func hasSegmentPrefix(s, prefix string) bool {
+	for {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+		i := strings.IndexAny(s, "/_")
+		if i < 0 {
+			return false
+		}
+		s = s[i+1:]
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuantityConversion is the result of converting to one requested unit.
+type QuantityConversion struct {
+	Type       string    `json:"type"` // always "quantity_conversion"
+	Category   string    `json:"category"`
+	Dimension  string    `json:"dimension,omitempty"`
+	Input      UnitValue `json:"input"`
+	Result     UnitValue `json:"result"`
+	Text       string    `json:"text"`                 // e.g. "180.34 cm"
+	Reciprocal bool      `json:"reciprocal,omitempty"` // converted through 1/x, as mpg to L/100km
+	// Interpretations gives the result for each reading of an ambiguous
+	// source or, failing that, target unit such as "mb".
+	Interpretations []UnitInterpretation `json:"interpretations,omitempty"`
+}
+
+const quantityConversionSchema = `{
+	"type": "object",
+	"properties": {
+		"type": {"const": "quantity_conversion"},
+		"category": {"type": "string"},
+		"dimension": {"type": "string"},
+		"input": {
+			"type": "object",
+			"properties": {"val": {"type": "number"}, "unit": {"type": "string"}, "exact": {"type": "string"}},
+			"required": ["val", "unit"]
+		},
+		"result": {
+			"type": "object",
+			"properties": {"val": {"type": "number"}, "unit": {"type": "string"}, "exact": {"type": "string"}},
+			"required": ["val", "unit"]
+		},
+		"text": {"type": "string"},
+		"reciprocal": {"type": "boolean"},
+		"interpretations": ` + interpretationsSchema + `
+	},
+	"required": ["type", "category", "input", "result", "text"]
+}`
+
+// convertTo converts valStr from one unit to another. Units of the unit
+// table convert within their category, temperatures included, and exact
+// categories give an exact decimal; anything else goes through
+// dimensional analysis. decimals overrides the decimals of a token.
+func convertTo(valStr string, from, to string, decimals *int) (*QuantityConversion, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		return nil, toolErrorf(KindInvalidInput, "'to' needs a numeric value, got %q", valStr)
+	}
+	res := &QuantityConversion{Type: "quantity_conversion", Input: UnitValue{Val: val, Unit: from}}
+
+	uf, altF, okF := resolveUnit(from)
+	ut, altT, okT := resolveUnit(to)
+	if okF && okT && !isDimensionalUnit(from) && !isDimensionalUnit(to) {
+		if uf.Category != ut.Category {
+			return nil, toolErrorf(KindUnsupportedUnit, "Cannot convert %s (%s) to %s (%s)", from, uf.Category, to, ut.Category)
+		}
+		c, _ := findCategory(uf.Category)
+		if c.Systems == systemsExclusive && uf.System != ut.System {
+			return nil, toolErrorf(KindUnsupportedUnit, "Cannot convert %s to %s: %s and %s units do not convert", from, to, uf.System, ut.System)
+		}
+		if err := checkDecimals(decimals, uf, ut); err != nil {
+			return nil, err
+		}
+		convert := func(a, b *unitDef) UnitValue {
+			return UnitValue{Val: b.fromBase(a.toBase(val)), Unit: to}
+		}
+		if c.Exact {
+			exact, err := parseRat(valStr)
+			if err == errNotDecimal {
+				return nil, toolErrorf(KindInvalidInput, "value %q is not a number", valStr)
+			} else if err != nil {
+				return nil, err
+			}
+			res.Input.Exact = ratString(exact)
+			convert = func(a, b *unitDef) UnitValue {
+				r := convertExact(exact, a, b, decimals)
+				return UnitValue{Val: ratFloat(r), Unit: to, Exact: ratString(r)}
+			}
+		}
+		res.Category = uf.Category
+		res.Result = convert(uf, ut)
+		// An ambiguous spelling gets one result per reading, the one used first
+		interpretation := func(u *unitDef, v UnitValue) UnitInterpretation {
+			return UnitInterpretation{Unit: u.Name, System: u.System, Value: v.Val, Exact: v.Exact}
+		}
+		switch {
+		case altF != nil:
+			res.Interpretations = []UnitInterpretation{interpretation(uf, res.Result), interpretation(altF, convert(altF, ut))}
+		case altT != nil:
+			res.Interpretations = []UnitInterpretation{interpretation(ut, res.Result), interpretation(altT, convert(uf, altT))}
+		}
+	} else {
+		if decimals != nil {
+			return nil, toolErrorf(KindInvalidInput, "decimals applies to token amounts such as erc20_raw")
+		}
+		if (okF && uf.Category == "temperature" && uf.Offset != 0) || (okT && ut.Category == "temperature" && ut.Offset != 0) {
+			return nil, toolErrorf(KindUnsupportedUnit, "Cannot convert %s to %s: temperatures only convert to other temperatures", from, to)
+		}
+		qf, err := parseUnitExpr(from)
+		if err != nil {
+			return nil, asUnitError(err)
+		}
+		qt, err := parseUnitExpr(to)
+		if err != nil {
+			return nil, asUnitError(err)
+		}
+		v, ok := convertQuantity(val, qf, qt)
+		if !ok {
+			return nil, toolErrorf(KindUnsupportedUnit, "Cannot convert %s (%s) to %s (%s): dimensions differ", from, qf.Dim, to, qt.Dim)
+		}
+		res.Category, _, _ = findDerivedQuantity(qf.Dim)
+		if res.Category == "" {
+			res.Category = "derived"
+		}
+		res.Dimension = qf.Dim.String()
+		res.Reciprocal = qf.Dim != qt.Dim
+		res.Result = UnitValue{Val: roundSig(v, conversionDigits), Unit: to}
+	}
+	floats := []float64{res.Input.Val, res.Result.Val}
+	for _, in := range res.Interpretations {
+		floats = append(floats, in.Value)
+	}
+	if err := checkFinite(valStr, floats...); err != nil {
+		return nil, err
+	}
+	res.Text = strconv.FormatFloat(res.Result.Val, 'g', -1, 64) + " " + to
+	if res.Result.Exact != "" {
+		res.Text = res.Result.Exact + " " + to
+	}
+	return res, nil
+}
+
+// asUnitError reports a unit expression that does not parse as an
+// unsupported unit.
+func asUnitError(err error) error {
+	if _, ok := err.(*ToolError); ok {
+		return err
+	}
+	return toolErrorf(KindUnsupportedUnit, "%v", err)
+}
+
+// textQuantity is a quantity read from free text by parseQuantityText.
+type textQuantity struct {
+	Value float64
+	Text  string // Value as written, without thousands separators
+	Unit  string
+	Known bool // Unit is a unit the converter understands
+}
+
+// quantityNumber matches a leading number, allowing thousands separators
+// ("1,024") and exponents.
+var quantityNumber = regexp.MustCompile(`^[+-]?(?:\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d*\.?\d+(?:[eE][+-]?\d+)?)`)
+
+// parseQuantityText reads a number with a unit from free text, such as
+// "3.2 kg", "1,024 MiB", "72°F", "€49.99" or 5'11". Several parts in units of one
+// category add up and are expressed in the last unit, so "5 ft 11 in" is
+// 71 in. ok is false when the text is not obviously a quantity, for
+// example a date like "02 Jan 2006"; a lone number has an empty Unit.
+func parseQuantityText(text string) (q textQuantity, ok bool) {
+	type part struct {
+		val  float64
+		text string
+		unit string
+	}
+	var parts []part
+	s := strings.TrimSpace(text)
+	// A currency sign before the amount, as in €49.99
+	for sign, code := range currencySymbols {
+		if rest, ok := strings.CutPrefix(s, sign); ok {
+			num := quantityNumber.FindString(strings.TrimSpace(rest))
+			if num == "" || strings.TrimSpace(rest) != num {
+				return q, false
+			}
+			num = strings.ReplaceAll(num, ",", "")
+			val, err := strconv.ParseFloat(num, 64)
+			return textQuantity{Value: val, Text: num, Unit: code, Known: true}, err == nil
+		}
+	}
+	for s != "" {
+		match := quantityNumber.FindString(s)
+		if match == "" {
+			return q, false
+		}
+		num := strings.ReplaceAll(match, ",", "")
+		val, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return q, false
+		}
+		s = strings.TrimLeftFunc(s[len(match):], unicode.IsSpace)
+		unit, rest := splitUnitText(s)
+		if unit == "" && (len(parts) > 0 || rest != "") {
+			return q, false
+		}
+		parts = append(parts, part{val, num, unit})
+		s = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	}
+	if len(parts) == 0 {
+		return q, false
+	}
+
+	if len(parts) == 1 {
+		q = textQuantity{Value: parts[0].val, Text: parts[0].text, Unit: parts[0].unit}
+		if q.Unit == "" {
+			return q, true
+		}
+		q.Known = knownUnit(q.Unit)
+		// An unknown single word is still clearly meant as a unit; an
+		// unknown phrase is more likely a date or some other text
+		return q, q.Known || !strings.ContainsFunc(q.Unit, unicode.IsSpace)
+	}
+
+	// Mixed units such as 5'11" or 1 h 30 min
+	last, ok := lookupUnit(parts[len(parts)-1].unit)
+	if !ok || last.Offset != 0 {
+		return q, false
+	}
+	total := 0.0
+	for _, p := range parts {
+		u, ok := lookupUnit(p.unit)
+		if !ok || u.Category != last.Category || u.Offset != 0 {
+			return q, false
+		}
+		total += u.toBase(p.val)
+	}
+	v := last.fromBase(total)
+	return textQuantity{Value: v, Text: strconv.FormatFloat(v, 'g', -1, 64), Unit: parts[len(parts)-1].unit, Known: true}, true
+}
+
+// splitUnitText takes the unit following a number off the front of s. A
+// unit starts with a letter or symbol and runs until the next number;
+// foot and inch marks end it immediately.
+func splitUnitText(s string) (unit, rest string) {
+	r, size := utf8.DecodeRuneInString(s)
+	switch r {
+	case '\'', '′', '’':
+		return "ft", s[size:]
+	case '"', '″', '”':
+		return "in", s[size:]
+	}
+	if s == "" || !(unicode.IsLetter(r) || strings.ContainsRune("°℉℃%µ", r)) {
+		return "", s
+	}
+	end := len(s)
+	for i, c := range s {
+		if unicode.IsSpace(c) {
+			if j := strings.IndexFunc(s[i:], func(c rune) bool { return !unicode.IsSpace(c) }); j >= 0 {
+				next, _ := utf8.DecodeRuneInString(s[i+j:])
+				if unicode.IsDigit(next) || next == '+' || next == '-' || next == '.' {
+					end = i
+					break
+				}
+			}
+		}
+	}
+	return normalizeUnitText(strings.TrimSpace(s[:end])), s[end:]
+}
+
+// normalizeUnitText maps degree signs onto the unit table's spellings.
+func normalizeUnitText(u string) string {
+	switch strings.ToLower(u) {
+	case "°f", "℉", "deg f", "degf":
+		return "f"
+	case "°c", "℃", "deg c", "degc":
+		return "c"
+	case "°k":
+		return "k"
+	}
+	return u
+}
+
+func knownUnit(u string) bool {
+	if _, ok := lookupUnit(u); ok || isCurrency(u) {
+		return true
+	}
+	_, err := parseUnitExpr(u)
+	return err == nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeConversion renders one instant in the formats of common languages
+// and libraries.
+type TimeConversion struct {
+	Type       string            `json:"type"` // always "time_conversion"
+	Original   string            `json:"original"`
+	Epoch      TimeEpoch         `json:"epoch"`
+	Formats    map[string]string `json:"formats"`
+	JavaScript map[string]string `json:"javascript"`
+	MomentJS   map[string]string `json:"momentjs"`
+	WorldClock map[string]string `json:"world_clock"`
+	Relative   string            `json:"relative"`
+	Zone       *TimeZoneInfo     `json:"zone"`
+	DST        *DSTTransition    `json:"dst,omitempty"` // set when a wall-clock input or output is ambiguous or skipped
+	Detected   *DateDetection    `json:"detected"`
+}
+
+type TimeEpoch struct {
+	Seconds      int64 `json:"seconds"`
+	Milliseconds int64 `json:"milliseconds"`
+}
+
+const timeConversionSchema = `{
+	"type": "object",
+	"properties": {
+		"type": {"const": "time_conversion"},
+		"original": {"type": "string"},
+		"epoch": {
+			"type": "object",
+			"properties": {"seconds": {"type": "integer"}, "milliseconds": {"type": "integer"}},
+			"required": ["seconds", "milliseconds"]
+		},
+		"formats": {"type": "object", "additionalProperties": {"type": "string"}},
+		"javascript": {"type": "object", "additionalProperties": {"type": "string"}},
+		"momentjs": {"type": "object", "additionalProperties": {"type": "string"}},
+		"world_clock": {"type": "object", "additionalProperties": {"type": "string"}},
+		"relative": {"type": "string"},
+		"zone": {
+			"type": "object",
+			"properties": {
+				"requested": {"type": "string"},
+				"name": {"type": "string"},
+				"abbreviation": {"type": "string"},
+				"offset": {"type": "string"},
+				"dst": {"type": "boolean"}
+			},
+			"required": ["name", "abbreviation", "offset", "dst"]
+		},
+		"dst": {
+			"type": "object",
+			"properties": {
+				"status": {"enum": ["ambiguous", "skipped"]},
+				"zone": {"type": "string"},
+				"wall_clock": {"type": "string"},
+				"candidates": {"type": "array", "items": {"type": "string"}},
+				"chosen": {"type": "string"},
+				"note": {"type": "string"}
+			},
+			"required": ["status", "zone", "wall_clock", "candidates", "chosen", "note"]
+		},
+		"detected": ` + dateDetectionSchema + `
+	},
+	"required": ["type", "original", "epoch", "formats", "javascript", "momentjs", "world_clock", "relative", "zone", "detected"]
+}`
+
+// Internal: Convert Time Logic. Inputs without an offset are wall-clock
+// times in zoneSpec; the result is rendered in renderSpec, or zoneSpec if
+// that is empty. Both default to UTC.
+func toolConvertTime(input string, zoneSpec string, renderSpec string, locale string) (interface{}, error) {
+	var t time.Time
+	var dst *DSTTransition
+	if _, _, ok := parseDateLocale(locale); !ok {
+		return nil, toolErrorf(KindInvalidInput, "Unknown locale %q; dates understand %s", locale, strings.Join(dateLocaleNames, ", "))
+	}
+
+	loc, ok := resolveZone(zoneSpec)
+	if !ok {
+		// Not a zone but a format hint such as "iso"
+		loc, zoneSpec = time.UTC, ""
+	}
+	if renderSpec == "" {
+		renderSpec = zoneSpec
+	}
+	render, ok := resolveZone(renderSpec)
+	if !ok {
+		return nil, toolErrorf(KindInvalidInput, "Unknown time zone: %s", renderSpec)
+	}
+
+	// Relative expressions first, then absolute dates in layers
+	var detected *DateDetection
+	if rel, ok := parseRelativeTime(input, time.Now().In(loc)); ok {
+		t, detected = rel, &DateDetection{Format: "relative", Confidence: 1}
+	} else if t, dst, detected, ok = detectDate(input, loc, locale); !ok {
+		return nil, toolErrorf(KindParseFailure, "Could not parse as time or unit: %s", input)
+	}
+
+	t = t.In(render)
+	if dst == nil {
+		// The rendered reading itself may occur twice
+		if _, flag := checkWallClock(t, render); flag != nil && flag.Status == "ambiguous" {
+			flag.Chosen = t.Format(time.RFC3339)
+			flag.Note = fmt.Sprintf("%s occurs twice in %s; the offset tells the two apart", flag.WallClock, render)
+			dst = flag
+		}
+	}
+
+	diff := time.Since(t)
+	rel := ""
+	if diff > 0 {
+		rel = fmt.Sprintf("%v ago", diff.Round(time.Second))
+	} else {
+		rel = fmt.Sprintf("in %v", (-diff).Round(time.Second))
+	}
+
+	return &TimeConversion{
+		Type:     "time_conversion",
+		Original: input,
+		Epoch: TimeEpoch{
+			Seconds:      t.Unix(),
+			Milliseconds: t.UnixMilli(),
+		},
+		Formats: map[string]string{
+			"iso":       t.Format(time.RFC3339),
+			"rfc2822":   t.Format(time.RFC1123),
+			"date_only": t.Format("2006-01-02"),
+			"time_only": t.Format("15:04:05"),
+			"human":     t.Format("Mon, 02 Jan 2006 15:04:05 MST"),
+		},
+		JavaScript: map[string]string{
+			"new_Date":           fmt.Sprintf("new Date(%d)", t.UnixMilli()),
+			"toISOString":        t.UTC().Format("2006-01-02T15:04:05.000Z"),
+			"toDateString":       t.Format("Mon Jan 02 2006"),
+			"toTimeString":       t.Format("15:04:05 GMT-0700 (MST)"),
+			"toLocaleString":     t.Format("1/2/2006, 3:04:05 PM"),
+			"toLocaleDateString": t.Format("1/2/2006"),
+			"toLocaleTimeString": t.Format("3:04:05 PM"),
+		},
+		MomentJS: map[string]string{
+			"format_default": t.Format("Mon Jan 02 2006 15:04:05 GMT-0700"),
+			"format_L":       t.Format("01/02/2006"),
+			"format_LL":      t.Format("January 2, 2006"),
+			"format_LLL":     t.Format("January 2, 2006 3:04 PM"),
+			"format_LLLL":    t.Format("Monday, January 2, 2006 3:04 PM"),
+			"format_LT":      t.Format("3:04 PM"),
+			"format_LTS":     t.Format("3:04:05 PM"),
+			"format_ISO":     t.Format(time.RFC3339),
+			"unix":           fmt.Sprintf("%d", t.Unix()),
+			"valueOf":        fmt.Sprintf("%d", t.UnixMilli()),
+			"fromNow":        formatRelativeMoment(diff),
+			"toNow":          formatRelativeMoment(-diff),
+		},
+		WorldClock: worldClock(t),
+		Relative:   rel,
+		Zone:       zoneInfo(renderSpec, t),
+		DST:        dst,
+		Detected:   detected,
+	}, nil
+}
+
+// commonTimeZones are the IANA zone names offered as completions. The
+// tz database has no portable listing, so this covers the populous and
+// commonly scheduled-against zones rather than all of them.
+var commonTimeZones = []string{
+	"UTC", "Africa/Cairo", "Africa/Johannesburg", "Africa/Lagos", "Africa/Nairobi",
+	"America/Anchorage", "America/Argentina/Buenos_Aires", "America/Bogota", "America/Chicago",
+	"America/Denver", "America/Halifax", "America/Los_Angeles", "America/Mexico_City",
+	"America/New_York", "America/Phoenix", "America/Sao_Paulo", "America/St_Johns", "America/Toronto",
+	"America/Vancouver", "Asia/Bangkok", "Asia/Dhaka", "Asia/Dubai", "Asia/Hong_Kong", "Asia/Jakarta",
+	"Asia/Jerusalem", "Asia/Karachi", "Asia/Kathmandu", "Asia/Kolkata", "Asia/Manila", "Asia/Seoul",
+	"Asia/Shanghai", "Asia/Singapore", "Asia/Taipei", "Asia/Tehran", "Asia/Tokyo",
+	"Atlantic/Reykjavik", "Australia/Adelaide", "Australia/Brisbane", "Australia/Perth",
+	"Australia/Sydney", "Europe/Amsterdam", "Europe/Athens", "Europe/Berlin", "Europe/Dublin",
+	"Europe/Istanbul", "Europe/Lisbon", "Europe/London", "Europe/Madrid", "Europe/Moscow",
+	"Europe/Paris", "Europe/Rome", "Europe/Stockholm", "Europe/Warsaw", "Europe/Zurich",
+	"Pacific/Auckland", "Pacific/Honolulu",
+}
+
+// relativeTimeGrammar documents the relative expressions accepted by
+// toolConvertTime and parseRelativeTime. It backs the
+// omni://time/relative-grammar resource and must be kept in sync.
+var relativeTimeGrammar = struct {
+	Keywords []string `json:"keywords"`
+	Patterns []string `json:"patterns"`
+	Units    []string `json:"units"`
+	Examples []string `json:"examples"`
+	Notes    string   `json:"notes"`
+}{
+	Keywords: []string{"now", "today", "tomorrow", "yesterday"},
+	Patterns: []string{
+		"in <offsets>",
+		"<offsets> ago",
+		"<offsets> from|after <expression>",
+		"<offsets> before <expression>",
+		"next|last <unit>",
+		"[next|last|this] <weekday>",
+		"<ordinal> <weekday> of <period>",
+		"start|beginning|end of <period>",
+	},
+	Units: []string{"second", "sec", "minute", "min", "hour", "hr", "day", "week", "wk", "fortnight", "month", "mo", "quarter", "qtr", "year", "yr", "business day", "working day"},
+	Examples: []string{
+		"in 4 days", "3 hours ago", "in 1.5 hours", "in 2 weeks and 3 days", "next month",
+		"next friday", "last monday of the month", "first tuesday of next month",
+		"end of quarter", "start of next week", "3 business days from now", "2 days before end of month",
+	},
+	Notes: "<offsets> is one or more '<number> <unit>' joined by 'and' or commas; 'a' or 'an' is 1 and units may be plural. " +
+		"<period> is [the|this|next|last] day|week|month|quarter|year. <ordinal> is first to fifth or last. " +
+		"Days, weeks, months and years follow the calendar in the input's zone: a month keeps the day of the month, clamped to the month's last day, so one month after Jan 31 is the last day of February. " +
+		"Months, quarters, years and business days take whole numbers. Business days skip Saturdays and Sundays but not holidays. " +
+		"Weekdays and periods resolve to midnight; 'end of' is the period's last second. Weeks start on Monday. " +
+		"'next friday' is the first Friday after today, 'last friday' the last one before today, and 'friday' or 'this friday' today or the next one.",
+}
+
+// formatRelativeMoment returns moment.js style relative time strings
+func formatRelativeMoment(d time.Duration) string {
+	abs := d
+	if abs < 0 {
+		abs = -abs
+	}
+
+	var value string
+	switch {
+	case abs < time.Second*45:
+		value = "a few seconds"
+	case abs < time.Minute*2:
+		value = "a minute"
+	case abs < time.Hour:
+		value = fmt.Sprintf("%d minutes", int(abs.Minutes()))
+	case abs < time.Hour*2:
+		value = "an hour"
+	case abs < time.Hour*24:
+		value = fmt.Sprintf("%d hours", int(abs.Hours()))
+	case abs < time.Hour*24*2:
+		value = "a day"
+	case abs < time.Hour*24*30:
+		value = fmt.Sprintf("%d days", int(abs.Hours()/24))
+	case abs < time.Hour*24*60:
+		value = "a month"
+	case abs < time.Hour*24*365:
+		value = fmt.Sprintf("%d months", int(abs.Hours()/(24*30)))
+	case abs < time.Hour*24*365*2:
+		value = "a year"
+	default:
+		value = fmt.Sprintf("%d years", int(abs.Hours()/(24*365)))
+	}
+
+	if d > 0 {
+		return value + " ago"
+	}
+	return "in " + value
+}
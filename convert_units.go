@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// UnitConversion is the result of converting a number between the units of
+// one category.
+type UnitConversion struct {
+	Type        string                 `json:"type"` // always "unit_conversion"
+	Category    string                 `json:"category"`
+	Dimension   string                 `json:"dimension,omitempty"` // SI base units, for compound unit expressions
+	Input       UnitValue              `json:"input"`
+	Conversions map[string]interface{} `json:"conversions"`
+	// Interpretations lists every reading of an ambiguous unit such as
+	// "mb", the first being the one Conversions uses.
+	Interpretations []UnitInterpretation `json:"interpretations,omitempty"`
+}
+
+// UnitInterpretation is one reading of an ambiguous unit spelling. Value
+// is the input in the category's base unit, or the result for a
+// conversion with a target unit.
+type UnitInterpretation struct {
+	Unit   string  `json:"unit"`
+	System string  `json:"system,omitempty"`
+	Value  float64 `json:"value"`
+	Exact  string  `json:"exact,omitempty"`
+}
+
+type UnitValue struct {
+	Val   float64 `json:"val"`
+	Unit  string  `json:"unit"`
+	Exact string  `json:"exact,omitempty"` // exact decimal, for crypto and digital
+}
+
+const unitConversionSchema = `{
+	"type": "object",
+	"properties": {
+		"type": {"const": "unit_conversion"},
+		"category": {"type": "string"},
+		"dimension": {"type": "string", "description": "SI base dimension of a compound unit expression, e.g. kg·m²·s⁻²"},
+		"input": {
+			"type": "object",
+			"properties": {"val": {"type": "number"}, "unit": {"type": "string"}, "exact": {"type": "string"}},
+			"required": ["val", "unit"]
+		},
+		"conversions": {"type": "object", "description": "Value in each unit of the category, grouped by system for length, weight and digital; exact decimal strings for crypto and digital"},
+		"interpretations": ` + interpretationsSchema + `
+	},
+	"required": ["type", "category", "input", "conversions"]
+}`
+
+const interpretationsSchema = `{
+	"type": "array",
+	"description": "Readings of an ambiguous unit such as mb (MiB or MB), the first one used",
+	"items": {
+		"type": "object",
+		"properties": {"unit": {"type": "string"}, "system": {"type": "string"}, "value": {"type": "number"}, "exact": {"type": "string"}},
+		"required": ["unit", "value"]
+	}
+}`
+
+// toolConvertUnits converts val from unit from to every other unit of
+// category cat.
+func toolConvertUnits(val float64, from string, cat string) (interface{}, error) {
+	from = strings.TrimSpace(from)
+	unit, alt, ok := resolveUnit(from)
+	if !ok || unit.Category != cat {
+		return nil, toolErrorf(KindUnsupportedUnit, "Unknown %s unit: %s", cat, from)
+	}
+	base := unit.toBase(val)
+
+	res := &UnitConversion{
+		Type:        "unit_conversion",
+		Category:    cat,
+		Input:       UnitValue{Val: val, Unit: from},
+		Conversions: unitConversions(cat, unit, func(u *unitDef) interface{} { return u.fromBase(base) }),
+	}
+	if alt != nil {
+		res.Interpretations = []UnitInterpretation{
+			{Unit: unit.Name, System: unit.System, Value: roundSig(base, conversionDigits)},
+			{Unit: alt.Name, System: alt.System, Value: roundSig(alt.toBase(val), conversionDigits)},
+		}
+	}
+	floats := conversionValues(res.Conversions)
+	for _, in := range res.Interpretations {
+		floats = append(floats, in.Value)
+	}
+	if err := checkFinite(strconv.FormatFloat(val, 'g', -1, 64)+" "+from, floats...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// conversionValues lists the float values of a conversions map, groups
+// included.
+func conversionValues(conversions map[string]interface{}) []float64 {
+	var vals []float64
+	for _, v := range conversions {
+		switch v := v.(type) {
+		case float64:
+			vals = append(vals, v)
+		case map[string]interface{}:
+			vals = append(vals, conversionValues(v)...)
+		}
+	}
+	return vals
+}
+
+// unitConversions applies convert to every unit of cat that from can be
+// converted to, grouped by system as the category asks.
+func unitConversions(cat string, from *unitDef, convert func(u *unitDef) interface{}) map[string]interface{} {
+	c, _ := findCategory(cat)
+	conversions := map[string]interface{}{}
+	for _, u := range categoryUnits(cat) {
+		switch {
+		case c.Systems == systemsGrouped:
+			group, _ := conversions[u.System].(map[string]interface{})
+			if group == nil {
+				group = map[string]interface{}{}
+				conversions[u.System] = group
+			}
+			group[u.Name] = convert(u)
+		case c.Systems == systemsExclusive && u.System != from.System:
+		default:
+			conversions[u.Name] = convert(u)
+		}
+	}
+	return conversions
+}
+
+// getBaseValue converts val in unit to the base unit of category cat, so
+// two quantities of one category can be compared.
+func getBaseValue(val float64, unit string, cat string) (float64, *unitDef, error) {
+	u, ok := lookupUnit(unit)
+	if !ok || u.Category != cat {
+		return 0, nil, toolErrorf(KindUnsupportedUnit, "Unknown %s unit: %s", cat, unit)
+	}
+	return u.toBase(val), u, nil
+}
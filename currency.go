@@ -0,0 +1,392 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Currency ---
+//
+// Exchange rates come from local files named by "rates" in the tool
+// config, never from the network: ECB reference rate XML (daily or
+// historical) or CSV in the ECB layout, a Date column followed by one
+// column per currency. Every day in a file is a snapshot; a conversion
+// uses the latest one, or the latest on or before as_of.
+
+// rateTable is one day of exchange rates, each the amount of a currency
+// worth one Base.
+type rateTable struct {
+	Source string // file name
+	Format string // "ecb-xml" or "csv"
+	Base   string
+	Date   time.Time
+	Rates  map[string]*big.Rat
+}
+
+var (
+	ratesMu    sync.RWMutex
+	rateTables []*rateTable // by Date; later files win on the same day
+)
+
+func setRateTables(tables []*rateTable) {
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].Date.Before(tables[j].Date) })
+	ratesMu.Lock()
+	rateTables = tables
+	ratesMu.Unlock()
+}
+
+// rateTableAsOf returns the latest snapshot on or before asOf, or the
+// latest of all if asOf is zero.
+func rateTableAsOf(asOf time.Time) (*rateTable, error) {
+	ratesMu.RLock()
+	defer ratesMu.RUnlock()
+	if len(rateTables) == 0 {
+		return nil, toolErrorf(KindUnsupportedUnit, "Currency conversion needs exchange rates: set \"rates\" in the --tool-config file")
+	}
+	if asOf.IsZero() {
+		return rateTables[len(rateTables)-1], nil
+	}
+	i := sort.Search(len(rateTables), func(i int) bool { return rateTables[i].Date.After(asOf) })
+	if i == 0 {
+		return nil, toolErrorf(KindInvalidInput, "No exchange rates on or before %s; the earliest table is %s", asOf.Format(time.DateOnly), rateTables[0].Date.Format(time.DateOnly))
+	}
+	return rateTables[i-1], nil
+}
+
+// loadRates reads the rate file at path, or every .xml and .csv file in
+// the directory at path. base is the currency CSV rates are quoted
+// against; ECB XML is always against EUR.
+func loadRates(path, base string) ([]*rateTable, error) {
+	if base == "" {
+		base = "EUR"
+	}
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = nil
+		for _, e := range entries {
+			if ext := strings.ToLower(filepath.Ext(e.Name())); !e.IsDir() && (ext == ".xml" || ext == ".csv") {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+	var tables []*rateTable
+	for _, f := range files {
+		t, err := loadRateFile(f, strings.ToUpper(base))
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t...)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("%s: no exchange rates found", path)
+	}
+	return tables, nil
+}
+
+func loadRateFile(path, base string) ([]*rateTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var tables []*rateTable
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		tables, err = parseECBXML(f)
+	} else {
+		tables, err = parseRateCSV(f, base)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, t := range tables {
+		t.Source = filepath.Base(path)
+	}
+	return tables, nil
+}
+
+// parseECBXML reads the ECB eurofxref format: Cube elements with a time
+// attribute holding one Cube per currency.
+func parseECBXML(r io.Reader) ([]*rateTable, error) {
+	var doc struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube>Cube"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	var tables []*rateTable
+	for _, day := range doc.Days {
+		t, err := newRateTable("ecb-xml", "EUR", day.Time)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range day.Rates {
+			if err := t.add(c.Currency, c.Rate); err != nil {
+				return nil, err
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// parseRateCSV reads a header of Date and currency codes, then one row
+// per day. Empty and N/A cells are skipped, as in ECB downloads.
+func parseRateCSV(r io.Reader, base string) ([]*rateTable, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 || !strings.EqualFold(strings.TrimSpace(rows[0][0]), "date") {
+		return nil, fmt.Errorf("want a header row starting with Date and at least one row of rates")
+	}
+	header := rows[0]
+	var tables []*rateTable
+	for _, row := range rows[1:] {
+		t, err := newRateTable("csv", base, row[0])
+		if err != nil {
+			return nil, err
+		}
+		for i := 1; i < len(row) && i < len(header); i++ {
+			cell := strings.TrimSpace(row[i])
+			if cell == "" || strings.EqualFold(cell, "N/A") {
+				continue
+			}
+			if err := t.add(header[i], cell); err != nil {
+				return nil, err
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func newRateTable(format, base, date string) (*rateTable, error) {
+	d, err := time.Parse(time.DateOnly, strings.TrimSpace(date))
+	if err != nil {
+		return nil, fmt.Errorf("bad date %q", date)
+	}
+	one := big.NewRat(1, 1)
+	return &rateTable{Format: format, Base: base, Date: d, Rates: map[string]*big.Rat{base: one}}, nil
+}
+
+func (t *rateTable) add(currency, rate string) error {
+	r, err := parseRat(rate)
+	if err != nil || r.Sign() <= 0 {
+		return fmt.Errorf("bad %s rate %q on %s", currency, rate, t.Date.Format(time.DateOnly))
+	}
+	t.Rates[strings.ToUpper(strings.TrimSpace(currency))] = r
+	return nil
+}
+
+// currencyMinorUnits are the ISO 4217 decimals of currencies that do not
+// use two.
+var currencyMinorUnits = map[string]int{
+	"JPY": 0, "KRW": 0, "ISK": 0, "CLP": 0, "VND": 0, "PYG": 0, "UGX": 0, "XAF": 0, "XOF": 0,
+	"BHD": 3, "KWD": 3, "OMR": 3, "JOD": 3, "TND": 3, "IQD": 3, "LYD": 3,
+}
+
+// currencyCodes are the ISO 4217 codes recognized as the currency
+// category before any rate file is read; codes of loaded tables count
+// too.
+var currencyCodes = []string{
+	"AED", "ARS", "AUD", "BGN", "BHD", "BRL", "CAD", "CHF", "CLP", "CNY", "COP", "CZK", "DKK", "EGP",
+	"EUR", "GBP", "HKD", "HUF", "IDR", "ILS", "INR", "ISK", "JOD", "JPY", "KES", "KRW", "KWD", "MXN",
+	"MYR", "NGN", "NOK", "NZD", "OMR", "PHP", "PKR", "PLN", "QAR", "RON", "RUB", "SAR", "SEK", "SGD",
+	"THB", "TND", "TRY", "TWD", "UAH", "USD", "VND", "ZAR",
+}
+
+// currencySymbols are the signs written before an amount, as in €49.99.
+var currencySymbols = map[string]string{"€": "EUR", "$": "USD", "£": "GBP", "¥": "JPY", "₹": "INR", "₩": "KRW", "₺": "TRY"}
+
+// isCurrency reports whether unit is a currency code. Unit table
+// spellings win, so "cup" stays a volume.
+func isCurrency(unit string) bool {
+	code := strings.ToUpper(strings.TrimSpace(unit))
+	if len(code) != 3 {
+		return false
+	}
+	if _, ok := lookupUnit(code); ok {
+		return false
+	}
+	if containsString(currencyCodes, code) {
+		return true
+	}
+	ratesMu.RLock()
+	defer ratesMu.RUnlock()
+	for _, t := range rateTables {
+		if _, ok := t.Rates[code]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrencyConversion is the result of converting an amount of money,
+// with the rate table that was used.
+type CurrencyConversion struct {
+	Type        string            `json:"type"` // always "currency_conversion"
+	Input       UnitValue         `json:"input"`
+	Result      *UnitValue        `json:"result,omitempty"`      // with a target currency
+	Rate        string            `json:"rate,omitempty"`        // target currency per input currency, as the table gives it
+	Conversions map[string]string `json:"conversions,omitempty"` // without a target: every currency of the table
+	Table       RateTableInfo     `json:"table"`
+	Text        string            `json:"text,omitempty"`
+}
+
+// RateTableInfo identifies the snapshot a conversion used.
+type RateTableInfo struct {
+	Source    string `json:"source"`
+	Format    string `json:"format"`
+	Base      string `json:"base"`
+	Timestamp string `json:"timestamp"`
+}
+
+const currencyConversionSchema = `{
+	"type": "object",
+	"properties": {
+		"type": {"const": "currency_conversion"},
+		"input": {
+			"type": "object",
+			"properties": {"val": {"type": "number"}, "unit": {"type": "string"}, "exact": {"type": "string"}},
+			"required": ["val", "unit"]
+		},
+		"result": {
+			"type": "object",
+			"properties": {"val": {"type": "number"}, "unit": {"type": "string"}, "exact": {"type": "string"}},
+			"required": ["val", "unit"]
+		},
+		"rate": {"type": "string"},
+		"conversions": {"type": "object", "additionalProperties": {"type": "string"}},
+		"table": {
+			"type": "object",
+			"properties": {
+				"source": {"type": "string"},
+				"format": {"type": "string"},
+				"base": {"type": "string"},
+				"timestamp": {"type": "string"}
+			},
+			"required": ["source", "format", "base", "timestamp"]
+		},
+		"text": {"type": "string"}
+	},
+	"required": ["type", "input", "table"]
+}`
+
+// toolConvertCurrency converts valStr in currency from to currency to, or
+// to every currency of the table if to is empty. Amounts are exact and
+// rounded half to even to the target's minor units.
+func toolConvertCurrency(valStr, from, to, asOf string) (interface{}, error) {
+	amount, err := parseRat(valStr)
+	if err == errNotDecimal {
+		return nil, toolErrorf(KindInvalidInput, "value %q is not an amount", valStr)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := checkFinite(valStr, ratFloat(amount)); err != nil {
+		return nil, err
+	}
+	var when time.Time
+	if asOf != "" {
+		if when, err = parseAsOf(asOf); err != nil {
+			return nil, err
+		}
+	}
+	t, err := rateTableAsOf(when)
+	if err != nil {
+		return nil, err
+	}
+	from = strings.ToUpper(strings.TrimSpace(from))
+	fromRate, ok := t.Rates[from]
+	if !ok {
+		return nil, toolErrorf(KindUnsupportedUnit, "No %s rate in %s for %s", from, t.Source, t.Date.Format(time.DateOnly))
+	}
+
+	res := &CurrencyConversion{
+		Type:  "currency_conversion",
+		Input: UnitValue{Val: ratFloat(amount), Unit: from, Exact: ratString(amount)},
+		Table: RateTableInfo{Source: t.Source, Format: t.Format, Base: t.Base, Timestamp: t.Date.Format(time.RFC3339)},
+	}
+	convert := func(code string) *big.Rat {
+		r := new(big.Rat).Quo(amount, fromRate)
+		return roundHalfEven(r.Mul(r, t.Rates[code]), minorUnits(code))
+	}
+	if to == "" {
+		res.Conversions = map[string]string{}
+		for code := range t.Rates {
+			res.Conversions[code] = convert(code).FloatString(minorUnits(code))
+		}
+		return res, nil
+	}
+
+	to = strings.ToUpper(strings.TrimSpace(to))
+	toRate, ok := t.Rates[to]
+	if !ok {
+		return nil, toolErrorf(KindUnsupportedUnit, "No %s rate in %s for %s", to, t.Source, t.Date.Format(time.DateOnly))
+	}
+	v := convert(to)
+	exact := v.FloatString(minorUnits(to))
+	res.Result = &UnitValue{Val: ratFloat(v), Unit: to, Exact: exact}
+	if err := checkFinite(valStr, res.Result.Val); err != nil {
+		return nil, err
+	}
+	res.Rate = ratString(roundHalfEven(new(big.Rat).Quo(toRate, fromRate), 10))
+	res.Text = exact + " " + to
+	return res, nil
+}
+
+func parseAsOf(s string) (time.Time, error) {
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, toolErrorf(KindInvalidInput, "as_of must be a date like 2024-01-31, got %q", s)
+}
+
+func minorUnits(code string) int {
+	if d, ok := currencyMinorUnits[code]; ok {
+		return d
+	}
+	return 2
+}
+
+// roundHalfEven rounds r to digits decimals, ties to even.
+func roundHalfEven(r *big.Rat, digits int) *big.Rat {
+	scale := pow10(digits)
+	n := new(big.Int).Mul(r.Num(), scale)
+	q, m := new(big.Int).QuoRem(n, r.Denom(), new(big.Int))
+	twice := new(big.Int).Abs(m)
+	twice.Lsh(twice, 1)
+	if c := twice.Cmp(r.Denom()); c > 0 || (c == 0 && q.Bit(0) == 1) {
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return new(big.Rat).SetFrac(q, scale)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"math/big"
+	"os"
+	"testing"
+	"time"
+)
+
+// useTestRates loads testdata/rates for the length of the test: ECB XML
+// for 2024-01-02 to 04 and CSV for 2024-02-01 and 02.
+func useTestRates(t *testing.T) {
+	t.Helper()
+	tables, err := loadRates("testdata/rates", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setRateTables(tables)
+	t.Cleanup(func() { setRateTables(nil) })
+}
+
+func TestParseECBXML(t *testing.T) {
+	f, err := os.Open("testdata/rates/eurofxref-hist.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tables, err := parseECBXML(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"2024-01-02": "1.0956", "2024-01-03": "1.0919", "2024-01-04": "1.0953"}
+	if len(tables) != len(want) {
+		t.Fatalf("got %d tables, want %d", len(tables), len(want))
+	}
+	for _, tb := range tables {
+		day := tb.Date.Format(time.DateOnly)
+		if tb.Base != "EUR" || tb.Format != "ecb-xml" {
+			t.Errorf("%s: base %s format %s, want EUR ecb-xml", day, tb.Base, tb.Format)
+		}
+		if got := tb.Rates["USD"].FloatString(4); got != want[day] {
+			t.Errorf("%s: USD %s, want %s", day, got, want[day])
+		}
+		if tb.Rates["EUR"].Cmp(big.NewRat(1, 1)) != 0 {
+			t.Errorf("%s: EUR %s, want 1", day, tb.Rates["EUR"].RatString())
+		}
+	}
+}
+
+func TestParseRateCSV(t *testing.T) {
+	f, err := os.Open("testdata/rates/eurofxref.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tables, err := parseRateCSV(f, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("got %d tables, want 2", len(tables))
+	}
+	feb1, feb2 := tables[0], tables[1]
+	if d := feb1.Date.Format(time.DateOnly); d != "2024-02-01" {
+		t.Errorf("first table is %s, want 2024-02-01", d)
+	}
+	if got := feb1.Rates["JPY"].FloatString(2); got != "158.83" {
+		t.Errorf("2024-02-01 JPY %s, want 158.83", got)
+	}
+	if _, ok := feb1.Rates["KWD"]; ok {
+		t.Error("2024-02-01 has a KWD rate, but the cell is N/A")
+	}
+	if got := feb2.Rates["KWD"].FloatString(5); got != "0.33466" {
+		t.Errorf("2024-02-02 KWD %s, want 0.33466", got)
+	}
+}
+
+func TestRateTableAsOf(t *testing.T) {
+	useTestRates(t)
+	for asOf, want := range map[string]string{
+		"":                     "2024-02-02",
+		"2024-01-02":           "2024-01-02",
+		"2024-01-03":           "2024-01-03",
+		"2024-01-31":           "2024-01-04",
+		"2024-02-01T23:59:59Z": "2024-02-01",
+		"2030-01-01":           "2024-02-02",
+	} {
+		var when time.Time
+		if asOf != "" {
+			var err error
+			if when, err = parseAsOf(asOf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tb, err := rateTableAsOf(when)
+		if err != nil {
+			t.Errorf("as_of %q: %v", asOf, err)
+			continue
+		}
+		if got := tb.Date.Format(time.DateOnly); got != want {
+			t.Errorf("as_of %q: table %s, want %s", asOf, got, want)
+		}
+	}
+	if _, err := rateTableAsOf(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("as_of before the first table: no error")
+	}
+}
+
+func TestConvertCurrencyMissingRate(t *testing.T) {
+	useTestRates(t)
+	_, err := toolConvertCurrency("10", "EUR", "KWD", "2024-02-01")
+	if te, ok := err.(*ToolError); !ok || te.Kind != KindUnsupportedUnit {
+		t.Errorf("KWD on 2024-02-01: error %v, want %s", err, KindUnsupportedUnit)
+	}
+	if _, err := toolConvertCurrency("10", "KWD", "EUR", "2024-01-03"); err == nil {
+		t.Error("KWD from the XML snapshot: no error")
+	}
+	if _, err := toolConvertCurrency("10", "EUR", "KWD", "2024-02-02"); err != nil {
+		t.Errorf("KWD on 2024-02-02: %v", err)
+	}
+}
+
+func TestConvertCurrencyRounding(t *testing.T) {
+	useTestRates(t)
+	for _, tc := range []struct {
+		value, from, to, asOf, want string
+	}{
+		{"100", "EUR", "USD", "2024-01-02", "109.56"},
+		{"100", "EUR", "JPY", "2024-01-02", "15552"},    // no minor units
+		{"1", "USD", "JPY", "2024-01-02", "142"},        // 141.949...
+		{"1", "EUR", "KWD", "2024-02-02", "0.335"},      // three decimals
+		{"0.5", "EUR", "JPY", "2024-02-01", "79"},       // 79.415
+		{"1", "GBP", "EUR", "2024-01-03", "1.16"},       // 1.15895...
+		{"-100", "EUR", "USD", "2024-02-02", "-108.83"}, // negative amounts
+	} {
+		res, err := toolConvertCurrency(tc.value, tc.from, tc.to, tc.asOf)
+		if err != nil {
+			t.Errorf("%s %s to %s: %v", tc.value, tc.from, tc.to, err)
+			continue
+		}
+		if got := res.(*CurrencyConversion).Result.Exact; got != tc.want {
+			t.Errorf("%s %s to %s on %s = %s, want %s", tc.value, tc.from, tc.to, tc.asOf, got, tc.want)
+		}
+	}
+}
+
+func TestRoundHalfEven(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		digits int
+		want   string
+	}{
+		{"0.125", 2, "0.12"},
+		{"0.135", 2, "0.14"},
+		{"0.1251", 2, "0.13"},
+		{"-0.125", 2, "-0.12"},
+		{"-0.135", 2, "-0.14"},
+		{"2.5", 0, "2"},
+		{"3.5", 0, "4"},
+		{"1.0005", 3, "1.000"},
+	} {
+		r, _ := new(big.Rat).SetString(tc.in)
+		if got := roundHalfEven(r, tc.digits).FloatString(tc.digits); got != tc.want {
+			t.Errorf("roundHalfEven(%s, %d) = %s, want %s", tc.in, tc.digits, got, tc.want)
+		}
+	}
+}
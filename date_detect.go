@@ -0,0 +1,570 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Date Detection ---
+//
+// detectDate reads an absolute time in layers, most specific first:
+// epoch numbers and spreadsheet serials, layouts with an offset or zone,
+// log timestamps, wall-clock ISO layouts, numeric dates whose day and
+// month may be swapped, and dates with month names in English or the
+// language of a locale hint. The result names the format that matched
+// and how sure the match is.
+
+// DateDetection describes how an absolute time input was read.
+type DateDetection struct {
+	Format     string  `json:"format"`           // e.g. "rfc3339", "syslog", "unix_milliseconds"
+	Layout     string  `json:"layout,omitempty"` // Go reference layout of layout-based formats
+	Confidence float64 `json:"confidence"`       // 0 to 1
+	// Ambiguity is "day_month" when day and month could be swapped and
+	// "serial_or_epoch" when a number could be an Excel serial or Unix
+	// seconds. Alternatives holds the other readings.
+	Ambiguity    string            `json:"ambiguity,omitempty"`
+	Alternatives []DateAlternative `json:"alternatives,omitempty"`
+	Note         string            `json:"note,omitempty"`
+}
+
+type DateAlternative struct {
+	Format string `json:"format"`
+	Time   string `json:"time"` // RFC 3339
+}
+
+const dateDetectionSchema = `{
+	"type": "object",
+	"properties": {
+		"format": {"type": "string"},
+		"layout": {"type": "string"},
+		"confidence": {"type": "number", "minimum": 0, "maximum": 1},
+		"ambiguity": {"enum": ["day_month", "serial_or_epoch"]},
+		"alternatives": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {"format": {"type": "string"}, "time": {"type": "string"}},
+				"required": ["format", "time"]
+			}
+		},
+		"note": {"type": "string"}
+	},
+	"required": ["format", "confidence"]
+}`
+
+// dateLayout is a Go layout detectDate tries.
+type dateLayout struct {
+	Format     string
+	Layout     string
+	Confidence float64
+	Wall       bool   // no offset: a wall-clock time in the source zone
+	Fill       string // "year" if the layout has no year, "date" if it has no date
+}
+
+// dateLayouts are tried in order; the first that parses wins. Layouts
+// with fractional seconds accept times without them.
+var dateLayouts = []dateLayout{
+	{"rfc3339", time.RFC3339Nano, 1, false, ""},
+	{"rfc3339", "2006-01-02 15:04:05.999999999Z07:00", 0.95, false, ""},
+	{"iso8601_basic_offset", "2006-01-02T15:04:05.999999999Z0700", 0.95, false, ""},
+	{"rfc1123z", time.RFC1123Z, 1, false, ""},
+	{"rfc1123", time.RFC1123, 1, false, ""},
+	{"rfc850", time.RFC850, 1, false, ""},
+	{"rfc822z", time.RFC822Z, 1, false, ""},
+	{"rfc822", time.RFC822, 1, false, ""},
+	{"ruby_date", time.RubyDate, 1, false, ""},
+	{"unix_date", time.UnixDate, 1, false, ""},
+	{"go_time_string", "2006-01-02 15:04:05.999999999 -0700 MST", 1, false, ""},
+	// Log timestamps
+	{"common_log", "02/Jan/2006:15:04:05 -0700", 0.95, false, ""},
+	{"ansic", time.ANSIC, 0.95, true, ""},
+	{"go_log", "2006/01/02 15:04:05.999999", 0.9, true, ""},
+	{"log4j", "2006-01-02 15:04:05,000", 0.9, true, ""},
+	{"syslog", "Jan _2 15:04:05.999999999", 0.8, true, "year"},
+	// Wall-clock ISO 8601
+	{"iso8601_local", "2006-01-02T15:04:05.999999999", 0.9, true, ""},
+	{"iso8601_local", "2006-01-02 15:04:05.999999999", 0.9, true, ""},
+	{"iso8601_local", "2006-01-02T15:04", 0.9, true, ""},
+	{"iso8601_local", "2006-01-02 15:04", 0.9, true, ""},
+	{"iso8601_date", "2006-01-02", 0.95, true, ""},
+	{"time_only", "15:04:05.999999999", 0.7, true, "date"},
+	{"time_only", "15:04", 0.6, true, "date"},
+	{"kitchen", "3:04PM", 0.7, true, "date"},
+	{"kitchen", "3:04 PM", 0.7, true, "date"},
+}
+
+// detectDate reads input as an absolute time. Wall-clock readings are in
+// loc and may come with a DST flag. locale, if not empty, names the
+// language of month names and the preferred order of numeric dates.
+func detectDate(input string, loc *time.Location, locale string) (time.Time, *DSTTransition, *DateDetection, bool) {
+	input = strings.TrimSpace(input)
+	monthFirst, lang, _ := parseDateLocale(locale)
+	now := time.Now().In(loc)
+
+	if t, det, ok := detectEpoch(input, loc); ok {
+		return t, nil, det, true
+	}
+	for _, l := range dateLayouts {
+		text := input
+		if l.Format == "kitchen" {
+			text = strings.ToUpper(text)
+		}
+		t, err := time.Parse(l.Layout, text)
+		if err != nil {
+			continue
+		}
+		det := &DateDetection{Format: l.Format, Layout: l.Layout, Confidence: l.Confidence}
+		switch l.Fill {
+		case "year":
+			t = t.AddDate(now.Year(), 0, 0)
+			det.Note = "no year given; the current year is assumed"
+		case "date":
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+			det.Note = "no date given; today is assumed"
+		}
+		if !l.Wall {
+			return resolveZoneAbbreviation(t), nil, det, true
+		}
+		t, dst := checkWallClock(t, loc)
+		return t, dst, det, true
+	}
+	if t, dst, det, ok := detectNumericDate(input, loc, monthFirst, locale); ok {
+		return t, dst, det, true
+	}
+	return detectMonthNameDate(input, loc, lang, now)
+}
+
+// resolveZoneAbbreviation re-reads a time parsed with a zone
+// abbreviation in the zone the abbreviation stands for: time.Parse gives
+// abbreviations it does not know a zero offset.
+func resolveZoneAbbreviation(t time.Time) time.Time {
+	name, off := t.Zone()
+	if off != 0 || t.Location() == time.UTC {
+		return t
+	}
+	switch strings.ToUpper(name) {
+	case "UTC", "GMT", "UT", "Z":
+		return t
+	}
+	if zone, ok := resolveZone(name); ok {
+		t, _ = checkWallClock(t, zone)
+	}
+	return t
+}
+
+// --- Epochs and Serials ---
+
+var epochNumber = regexp.MustCompile(`^[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?$`)
+
+// Excel serials between these are read as dates, 1954 to 2119; smaller
+// numbers are more likely Unix seconds.
+const (
+	minExcelSerial = 20000
+	maxExcelSerial = 80000
+)
+
+// epochUnits classify a Unix timestamp by magnitude: a seconds value
+// below 1e11 is before the year 5000, so anything larger is a finer unit.
+var epochUnits = []struct {
+	Format string
+	Below  float64
+	Nanos  int64 // nanoseconds per unit
+}{
+	{"unix_seconds", 1e11, 1e9},
+	{"unix_milliseconds", 1e14, 1e6},
+	{"unix_microseconds", 1e17, 1e3},
+	{"unix_nanoseconds", 1e20, 1},
+}
+
+// detectEpoch reads a number as Unix seconds, milliseconds, microseconds
+// or nanoseconds by its magnitude, or as an Excel serial date.
+func detectEpoch(input string, loc *time.Location) (time.Time, *DateDetection, bool) {
+	if !epochNumber.MatchString(input) {
+		return time.Time{}, nil, false
+	}
+	r, err := parseRat(input)
+	if err != nil {
+		return time.Time{}, nil, false
+	}
+	v := ratFloat(r)
+	abs := v
+	if abs < 0 {
+		abs = -abs
+	}
+
+	if v >= minExcelSerial && v < maxExcelSerial {
+		t, _ := checkWallClock(excelSerialTime(r), loc)
+		det := &DateDetection{Format: "excel_serial", Confidence: 0.8}
+		if r.IsInt() {
+			// A whole number is as likely Unix seconds in 1970
+			det.Confidence = 0.6
+		}
+		det.Ambiguity = "serial_or_epoch"
+		det.Alternatives = []DateAlternative{{Format: "unix_seconds", Time: epochTime(r, 1e9).In(loc).Format(time.RFC3339Nano)}}
+		det.Note = "read as an Excel serial date (days since 1899-12-30) in the source zone"
+		return t, det, true
+	}
+	for _, u := range epochUnits {
+		if abs < u.Below {
+			t := epochTime(r, u.Nanos)
+			det := &DateDetection{Format: u.Format, Confidence: 0.95}
+			if y := t.Year(); y < 1990 || y > 2100 {
+				det.Confidence = 0.6
+			}
+			return t, det, true
+		}
+	}
+	return time.Time{}, nil, false
+}
+
+// epochTime is r units of nanos nanoseconds after the Unix epoch, exact
+// to the nanosecond.
+func epochTime(r *big.Rat, nanos int64) time.Time {
+	ns := new(big.Rat).Mul(r, new(big.Rat).SetInt64(nanos))
+	total := new(big.Int).Quo(ns.Num(), ns.Denom())
+	sec, nsec := total.QuoRem(total, big.NewInt(1e9), new(big.Int))
+	return time.Unix(sec.Int64(), nsec.Int64()).UTC()
+}
+
+// excelSerialTime is the wall-clock time of an Excel serial in the 1900
+// date system, counted from 1899-12-30 so that serials after Excel's
+// phantom 1900-02-29 come out right.
+func excelSerialTime(r *big.Rat) time.Time {
+	ms := new(big.Rat).Mul(r, new(big.Rat).SetInt64(86400000))
+	f, _ := ms.Float64()
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).Add(time.Duration(f+0.5) * time.Millisecond)
+}
+
+// --- Numeric Dates ---
+
+var numericDate = regexp.MustCompile(`^(\d{1,4})([/.-])(\d{1,2})([/.-])(\d{1,4})(?:[ T]+(\d{1,2}):(\d{2})(?::(\d{2})(?:[.,](\d{1,9}))?)?\s*([AaPp][Mm])?)?$`)
+
+// detectNumericDate reads dates such as 2006/01/02, 01/02/2006 or
+// 02.01.2006, optionally followed by a time. When both day-month orders
+// are valid it picks the locale's and lists the other.
+func detectNumericDate(input string, loc *time.Location, monthFirst bool, locale string) (time.Time, *DSTTransition, *DateDetection, bool) {
+	m := numericDate.FindStringSubmatch(input)
+	if m == nil || m[2] != m[4] {
+		return time.Time{}, nil, nil, false
+	}
+	sep := m[2]
+	hour, minute, sec, nsec, ok := clockFields(m[6], m[7], m[8], m[9], m[10])
+	if !ok {
+		return time.Time{}, nil, nil, false
+	}
+	build := func(y, mo, d int) (time.Time, bool) {
+		if mo < 1 || mo > 12 || d < 1 || d > time.Date(y, time.Month(mo)+1, 0, 0, 0, 0, 0, time.UTC).Day() {
+			return time.Time{}, false
+		}
+		return time.Date(y, time.Month(mo), d, hour, minute, sec, nsec, time.UTC), true
+	}
+	a, _ := strconv.Atoi(m[1])
+	b, _ := strconv.Atoi(m[3])
+	c, _ := strconv.Atoi(m[5])
+	conf := 0.9
+	if len(m[1]) == 4 {
+		wall, ok := build(a, b, c)
+		if !ok || len(m[5]) > 2 {
+			return time.Time{}, nil, nil, false
+		}
+		t, dst := checkWallClock(wall, loc)
+		return t, dst, &DateDetection{Format: "numeric_ymd", Confidence: 0.95}, true
+	}
+	if len(m[5]) != 4 && len(m[5]) != 2 || len(m[1]) > 2 {
+		return time.Time{}, nil, nil, false
+	}
+	if len(m[5]) == 2 {
+		// time.Parse's pivot: 69 to 99 are the 1900s
+		c += 2000
+		if c >= 2069 {
+			c -= 100
+		}
+		conf -= 0.1
+	}
+
+	mdy, mdyOK := build(c, a, b)
+	dmy, dmyOK := build(c, b, a)
+	preferMDY := monthFirst && sep != "."
+	order := map[bool]string{true: "numeric_mdy", false: "numeric_dmy"}
+	var wall time.Time
+	det := &DateDetection{Confidence: conf}
+	switch {
+	case mdyOK && dmyOK && a != b:
+		wall, det.Format = dmy, order[false]
+		other, otherFormat := mdy, order[true]
+		if preferMDY {
+			wall, det.Format = mdy, order[true]
+			other, otherFormat = dmy, order[false]
+		}
+		alt, _ := checkWallClock(other, loc)
+		det.Confidence = conf - 0.4
+		det.Ambiguity = "day_month"
+		det.Alternatives = []DateAlternative{{Format: otherFormat, Time: alt.Format(time.RFC3339Nano)}}
+		hint := "month first, as in the US"
+		if !preferMDY {
+			hint = "day first"
+		}
+		det.Note = fmt.Sprintf("%s%s%s could be month%sday or day%smonth; read %s", m[1], sep, m[3], sep, sep, hint)
+		if locale == "" {
+			det.Note += ". Pass locale to choose"
+		} else {
+			det.Note += " for locale " + locale
+		}
+	case mdyOK && (preferMDY || !dmyOK):
+		wall, det.Format = mdy, order[true]
+	case dmyOK:
+		wall, det.Format = dmy, order[false]
+	default:
+		return time.Time{}, nil, nil, false
+	}
+	t, dst := checkWallClock(wall, loc)
+	return t, dst, det, true
+}
+
+// clockFields converts the time matched after a date; all empty is
+// midnight.
+func clockFields(h, mi, s, frac, ampm string) (hour, minute, sec, nsec int, ok bool) {
+	if h == "" {
+		return 0, 0, 0, 0, true
+	}
+	hour, _ = strconv.Atoi(h)
+	minute, _ = strconv.Atoi(mi)
+	sec, _ = strconv.Atoi("0" + s)
+	if frac != "" {
+		nsec, _ = strconv.Atoi((frac + "00000000")[:9])
+	}
+	switch strings.ToUpper(ampm) {
+	case "AM", "PM":
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, 0, false
+		}
+		hour %= 12
+		if strings.EqualFold(ampm, "PM") {
+			hour += 12
+		}
+	}
+	return hour, minute, sec, nsec, hour < 24 && minute < 60 && sec < 60
+}
+
+// --- Month Names ---
+
+// dateLanguage lists the words of one language's dates: month names and
+// abbreviations, weekday names, and filler words such as Spanish "de".
+type dateLanguage struct {
+	Months   map[string]time.Month
+	Weekdays []string
+	Filler   []string
+}
+
+// dateLanguages are the languages a locale hint can select. English is
+// always understood.
+var dateLanguages = map[string]dateLanguage{
+	"en": {
+		Months: map[string]time.Month{
+			"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+			"jul": 7, "aug": 8, "sep": 9, "sept": 9, "oct": 10, "nov": 11, "dec": 12,
+		},
+		Filler: []string{"the", "of", "on", "at"},
+	},
+	"de": {
+		Months: map[string]time.Month{
+			"januar": 1, "jänner": 1, "jan": 1, "jän": 1, "februar": 2, "feb": 2,
+			"märz": 3, "maerz": 3, "mär": 3, "mrz": 3, "april": 4, "apr": 4, "mai": 5,
+			"juni": 6, "jun": 6, "juli": 7, "jul": 7, "august": 8, "aug": 8,
+			"september": 9, "sep": 9, "sept": 9, "oktober": 10, "okt": 10,
+			"november": 11, "nov": 11, "dezember": 12, "dez": 12,
+		},
+		Weekdays: []string{"montag", "dienstag", "mittwoch", "donnerstag", "freitag", "samstag", "sonnabend", "sonntag"},
+		Filler:   []string{"den", "am", "um", "uhr"},
+	},
+	"fr": {
+		Months: map[string]time.Month{
+			"janvier": 1, "janv": 1, "février": 2, "fevrier": 2, "févr": 2, "fevr": 2,
+			"mars": 3, "avril": 4, "avr": 4, "mai": 5, "juin": 6, "juillet": 7, "juil": 7,
+			"août": 8, "aout": 8, "septembre": 9, "sept": 9, "octobre": 10, "oct": 10,
+			"novembre": 11, "nov": 11, "décembre": 12, "decembre": 12, "déc": 12, "dec": 12,
+		},
+		Weekdays: []string{"lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi", "dimanche"},
+		Filler:   []string{"le", "à"},
+	},
+	"es": {
+		Months: map[string]time.Month{
+			"enero": 1, "ene": 1, "febrero": 2, "feb": 2, "marzo": 3, "mar": 3,
+			"abril": 4, "abr": 4, "mayo": 5, "may": 5, "junio": 6, "jun": 6,
+			"julio": 7, "jul": 7, "agosto": 8, "ago": 8, "septiembre": 9, "setiembre": 9,
+			"sep": 9, "sept": 9, "octubre": 10, "oct": 10, "noviembre": 11, "nov": 11,
+			"diciembre": 12, "dic": 12,
+		},
+		Weekdays: []string{"lunes", "martes", "miércoles", "miercoles", "jueves", "viernes", "sábado", "sabado", "domingo"},
+		Filler:   []string{"de", "del", "a", "las"},
+	},
+	"it": {
+		Months: map[string]time.Month{
+			"gennaio": 1, "gen": 1, "febbraio": 2, "feb": 2, "marzo": 3, "mar": 3,
+			"aprile": 4, "apr": 4, "maggio": 5, "mag": 5, "giugno": 6, "giu": 6,
+			"luglio": 7, "lug": 7, "agosto": 8, "ago": 8, "settembre": 9, "set": 9,
+			"ottobre": 10, "ott": 10, "novembre": 11, "nov": 11, "dicembre": 12, "dic": 12,
+		},
+		Weekdays: []string{"lunedì", "lunedi", "martedì", "martedi", "mercoledì", "mercoledi", "giovedì", "giovedi", "venerdì", "venerdi", "sabato", "domenica"},
+		Filler:   []string{"il", "alle"},
+	},
+	"pt": {
+		Months: map[string]time.Month{
+			"janeiro": 1, "jan": 1, "fevereiro": 2, "fev": 2, "março": 3, "marco": 3, "mar": 3,
+			"abril": 4, "abr": 4, "maio": 5, "mai": 5, "junho": 6, "jun": 6,
+			"julho": 7, "jul": 7, "agosto": 8, "ago": 8, "setembro": 9, "set": 9,
+			"outubro": 10, "out": 10, "novembro": 11, "nov": 11, "dezembro": 12, "dez": 12,
+		},
+		Weekdays: []string{"segunda", "terça", "terca", "quarta", "quinta", "sexta", "feira", "sábado", "sabado", "domingo"},
+		Filler:   []string{"de", "às", "as"},
+	},
+	"nl": {
+		Months: map[string]time.Month{
+			"januari": 1, "jan": 1, "februari": 2, "feb": 2, "maart": 3, "mrt": 3,
+			"april": 4, "apr": 4, "mei": 5, "juni": 6, "jun": 6, "juli": 7, "jul": 7,
+			"augustus": 8, "aug": 8, "september": 9, "sep": 9, "oktober": 10, "okt": 10,
+			"november": 11, "nov": 11, "december": 12, "dec": 12,
+		},
+		Weekdays: []string{"maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag", "zondag"},
+		Filler:   []string{"om"},
+	},
+}
+
+// dateLocaleNames lists example locale hints, for completion and errors.
+var dateLocaleNames = []string{"en-US", "en-GB", "de", "fr", "es", "it", "pt", "nl"}
+
+// parseDateLocale splits a locale hint such as "en-GB" or "de_DE" into
+// its language and whether numeric dates put the month first, as only
+// US English does. An empty hint is en-US.
+func parseDateLocale(locale string) (monthFirst bool, lang string, ok bool) {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if locale == "" {
+		return true, "en", true
+	}
+	lang, region, _ := strings.Cut(locale, "-")
+	if _, ok := dateLanguages[lang]; !ok {
+		return false, "", false
+	}
+	return lang == "en" && (region == "" || region == "us"), lang, true
+}
+
+// monthNameDateLayouts are tried against month-name dates once
+// normalizeDateText has rewritten them in English without punctuation.
+var monthNameDateLayouts = func() []string {
+	var layouts []string
+	for _, d := range []string{"2 January 2006", "January 2 2006", "2006 January 2"} {
+		for _, t := range []string{"", " 15:04", " 15:04:05.999999999", " 3:04 PM", " 3:04PM", " 3 PM", " 3PM"} {
+			layouts = append(layouts, d+t)
+		}
+	}
+	return layouts
+}()
+
+var (
+	dateOrdinal = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th|er|e|º|ª|\.)$`)
+	dateJoiner  = regexp.MustCompile(`[\s,/-]+`)
+)
+
+// detectMonthNameDate reads dates such as "2 January 2006", "Jan 2,
+// 2006 3:04 PM" or, with locale de, "Montag, 2. Januar 2006".
+func detectMonthNameDate(input string, loc *time.Location, lang string, now time.Time) (time.Time, *DSTTransition, *DateDetection, bool) {
+	text, ok := normalizeDateText(input, lang)
+	if !ok {
+		return time.Time{}, nil, nil, false
+	}
+	conf := 0.9
+	if lang != "en" {
+		conf = 0.85
+	}
+	try := func(text string) (time.Time, string, bool) {
+		for _, l := range monthNameDateLayouts {
+			if t, err := time.Parse(l, text); err == nil {
+				return t, l, true
+			}
+		}
+		return time.Time{}, "", false
+	}
+	wall, layout, ok := try(text)
+	var notes []string
+	if !ok {
+		// No year: try again with the current one
+		if wall, layout, ok = try(fmt.Sprintf("%s %d", text, now.Year())); !ok {
+			return time.Time{}, nil, nil, false
+		}
+		conf -= 0.2
+		notes = append(notes, "no year given; the current year is assumed")
+	}
+	if text != input {
+		notes = append(notes, fmt.Sprintf("read as %q", text))
+	}
+	t, dst := checkWallClock(wall, loc)
+	return t, dst, &DateDetection{Format: "month_name", Layout: layout, Confidence: conf, Note: strings.Join(notes, "; ")}, true
+}
+
+// normalizeDateText rewrites month names of English and lang as English
+// month names, drops weekdays, filler words and punctuation, and strips
+// ordinal suffixes. ok is false if no month name was found.
+func normalizeDateText(input, lang string) (string, bool) {
+	langs := []dateLanguage{dateLanguages["en"]}
+	if lang != "en" {
+		langs = append(langs, dateLanguages[lang])
+	}
+	var words []string
+	found := false
+	// A hyphen before an offset is not a separator, but month-name dates
+	// carry no offset
+	for _, w := range dateJoiner.Split(strings.ToLower(strings.TrimSpace(input)), -1) {
+		key := strings.TrimSuffix(w, ".")
+		if key == "" {
+			continue
+		}
+		if m := dateOrdinal.FindStringSubmatch(w); m != nil {
+			words = append(words, m[1])
+			continue
+		}
+		if mo, ok := englishMonth(key, langs); ok {
+			words = append(words, mo.String())
+			found = true
+			continue
+		}
+		if _, ok := relativeWeekdays[key]; ok || isDateFiller(key, langs) {
+			continue
+		}
+		if strings.HasSuffix(key, "am") || strings.HasSuffix(key, "pm") {
+			key = strings.ToUpper(key)
+		}
+		words = append(words, key)
+	}
+	return strings.Join(words, " "), found
+}
+
+// englishMonth looks word up as a full English month name or a month
+// name of langs.
+func englishMonth(word string, langs []dateLanguage) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if strings.EqualFold(word, m.String()) {
+			return m, true
+		}
+	}
+	for _, l := range langs {
+		if m, ok := l.Months[word]; ok {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func isDateFiller(word string, langs []dateLanguage) bool {
+	for _, l := range langs {
+		for _, w := range append(l.Weekdays, l.Filler...) {
+			if w == word {
+				return true
+			}
+		}
+	}
+	return false
+}
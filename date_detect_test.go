@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectDate(t *testing.T) {
+	for _, tc := range []struct {
+		input, locale   string
+		format, want    string // want is RFC 3339; format "" if input is not a date
+		ambiguity, note string
+	}{
+		{"2024-01-02T03:04:05Z", "", "rfc3339", "2024-01-02T03:04:05Z", "", ""},
+		{"2024-01-02 03:04:05.5+01:00", "", "rfc3339", "2024-01-02T03:04:05.5+01:00", "", ""},
+		{"2024-01-02T03:04:05+0530", "", "iso8601_basic_offset", "2024-01-02T03:04:05+05:30", "", ""},
+		{"Tue, 02 Jan 2024 03:04:05 PST", "", "rfc1123", "2024-01-02T03:04:05-08:00", "", ""},
+		{"02/Jan/2024:03:04:05 -0700", "", "common_log", "2024-01-02T03:04:05-07:00", "", ""},
+		{"2024/01/02 03:04:05", "", "go_log", "2024-01-02T03:04:05Z", "", ""},
+		{"2024-01-02 03:04:05,123", "", "log4j", "2024-01-02T03:04:05.123Z", "", ""},
+		{"2024-01-02 03:04", "", "iso8601_local", "2024-01-02T03:04:00Z", "", ""},
+		{"2024-01-02", "", "iso8601_date", "2024-01-02T00:00:00Z", "", ""},
+		{"1704164645", "", "unix_seconds", "2024-01-02T03:04:05Z", "", ""},
+		{"1704164645123", "", "unix_milliseconds", "2024-01-02T03:04:05.123Z", "", ""},
+		{"45293", "", "excel_serial", "2024-01-02T00:00:00Z", "serial_or_epoch", "read as an Excel serial date (days since 1899-12-30) in the source zone"},
+		{"2024/1/2", "", "numeric_ymd", "2024-01-02T00:00:00Z", "", ""},
+		{"13/01/2024", "", "numeric_dmy", "2024-01-13T00:00:00Z", "", ""},
+		{"01/13/2024 3:04 PM", "en-GB", "numeric_mdy", "2024-01-13T15:04:00Z", "", ""},
+		{"05/05/2024", "", "numeric_mdy", "2024-05-05T00:00:00Z", "", ""},
+		{"01/02/2024", "", "numeric_mdy", "2024-01-02T00:00:00Z", "day_month", "01/02 could be month/day or day/month; read month first, as in the US. Pass locale to choose"},
+		{"01/02/2024", "en-GB", "numeric_dmy", "2024-02-01T00:00:00Z", "day_month", "01/02 could be month/day or day/month; read day first for locale en-GB"},
+		{"01.02.24", "", "numeric_dmy", "2024-02-01T00:00:00Z", "day_month", "01.02 could be month.day or day.month; read day first. Pass locale to choose"},
+		{"2 January 2024", "", "month_name", "2024-01-02T00:00:00Z", "", ""},
+		{"Jan 2, 2024 3:04 PM", "", "month_name", "2024-01-02T15:04:00Z", "", `read as "January 2 2024 3:04 PM"`},
+		{"Dienstag, 2. Januar 2024", "de", "month_name", "2024-01-02T00:00:00Z", "", `read as "2 January 2024"`},
+		{"32/01/2024", "", "", "", "", ""},
+		{"2024-13-01", "", "", "", "", ""},
+		{"not a date", "", "", "", "", ""},
+	} {
+		got, _, det, ok := detectDate(tc.input, time.UTC, tc.locale)
+		switch {
+		case tc.format == "" && ok:
+			t.Errorf("detectDate(%q) = %s as %s, want no match", tc.input, got.Format(time.RFC3339Nano), det.Format)
+		case tc.format == "":
+		case !ok:
+			t.Errorf("detectDate(%q, %q): no match, want %s", tc.input, tc.locale, tc.format)
+		case det.Format != tc.format || got.Format(time.RFC3339Nano) != tc.want:
+			t.Errorf("detectDate(%q, %q) = %s as %s, want %s as %s", tc.input, tc.locale, got.Format(time.RFC3339Nano), det.Format, tc.want, tc.format)
+		case det.Ambiguity != tc.ambiguity || det.Note != tc.note:
+			t.Errorf("detectDate(%q, %q): ambiguity %q, note %q; want %q, %q", tc.input, tc.locale, det.Ambiguity, det.Note, tc.ambiguity, tc.note)
+		}
+	}
+}
+
+func TestDetectDateAlternatives(t *testing.T) {
+	for _, tc := range []struct {
+		input, locale string
+		alt           DateAlternative
+	}{
+		{"01/02/2024", "", DateAlternative{"numeric_dmy", "2024-02-01T00:00:00+01:00"}},
+		{"01/02/2024", "de", DateAlternative{"numeric_mdy", "2024-01-02T00:00:00+01:00"}},
+		{"45293", "", DateAlternative{"unix_seconds", "1970-01-01T13:34:53+01:00"}},
+	} {
+		berlin, _ := resolveZone("Europe/Berlin")
+		_, _, det, ok := detectDate(tc.input, berlin, tc.locale)
+		if !ok || len(det.Alternatives) != 1 || det.Alternatives[0] != tc.alt {
+			t.Errorf("detectDate(%q, %q): alternatives %+v, want %+v", tc.input, tc.locale, det, tc.alt)
+		}
+	}
+}
+
+func TestParseDateLocale(t *testing.T) {
+	for _, tc := range []struct {
+		locale     string
+		monthFirst bool
+		lang       string
+		ok         bool
+	}{
+		{"", true, "en", true},
+		{"en", true, "en", true},
+		{"en-US", true, "en", true},
+		{"en_GB", false, "en", true},
+		{"de-DE", false, "de", true},
+		{"FR", false, "fr", true},
+		{"xx", false, "", false},
+	} {
+		monthFirst, lang, ok := parseDateLocale(tc.locale)
+		if monthFirst != tc.monthFirst || lang != tc.lang || ok != tc.ok {
+			t.Errorf("parseDateLocale(%q) = %v, %q, %v; want %v, %q, %v", tc.locale, monthFirst, lang, ok, tc.monthFirst, tc.lang, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,609 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// --- Dimensional Analysis ---
+//
+// Compound unit expressions such as kg*m/s^2, N·m, g/cm³ or L/100km are
+// parsed into a scale factor and a vector of base dimensions. Two
+// expressions convert into each other when their dimensions match, or
+// are exact inverses as with mpg and L/100km.
+
+// baseDimensions are the SI base units plus the bit for information.
+var baseDimensions = [...]string{"kg", "m", "s", "A", "K", "mol", "cd", "bit"}
+
+const (
+	dimMass = iota
+	dimLength
+	dimTime
+	dimCurrent
+	dimTemperature
+	dimAmount
+	dimLuminosity
+	dimInformation
+)
+
+// dimension holds the exponent of each base dimension.
+type dimension [len(baseDimensions)]int
+
+func (d dimension) times(o dimension, sign int) dimension {
+	for i := range d {
+		d[i] += sign * o[i]
+	}
+	return d
+}
+
+func (d dimension) inverse() dimension {
+	return dimension{}.times(d, -1)
+}
+
+// String renders d in SI base units, e.g. kg·m²·s⁻².
+func (d dimension) String() string {
+	var parts []string
+	for i, e := range d {
+		if e == 0 {
+			continue
+		}
+		s := baseDimensions[i]
+		if e != 1 {
+			s += superscript(e)
+		}
+		parts = append(parts, s)
+	}
+	if len(parts) == 0 {
+		return "1"
+	}
+	return strings.Join(parts, "·")
+}
+
+const superscriptDigits = "⁰¹²³⁴⁵⁶⁷⁸⁹"
+
+func superscript(n int) string {
+	var b strings.Builder
+	if n < 0 {
+		b.WriteRune('⁻')
+		n = -n
+	}
+	for _, c := range strconv.Itoa(n) {
+		b.WriteRune([]rune(superscriptDigits)[c-'0'])
+	}
+	return b.String()
+}
+
+// quantity is a unit expression reduced to SI: one of it is Factor of the
+// coherent SI unit of dimension Dim.
+type quantity struct {
+	Factor float64
+	Dim    dimension
+}
+
+func (q quantity) times(o quantity, sign int) quantity {
+	return quantity{Factor: q.Factor * math.Pow(o.Factor, float64(sign)), Dim: q.Dim.times(o.Dim, sign)}
+}
+
+func (q quantity) pow(n int) quantity {
+	var d dimension
+	for i := range d {
+		d[i] = q.Dim[i] * n
+	}
+	return quantity{Factor: math.Pow(q.Factor, float64(n)), Dim: d}
+}
+
+// dimensionalUnits defines the symbols the expression parser knows, each
+// as a factor times an expression of symbols defined before it. Base
+// units have an empty expression and name their dimension instead.
+// Symbols are case-sensitive: MB is a megabyte, mb falls back to the unit
+// table's binary megabyte.
+var dimensionalUnits = []struct {
+	Symbol string
+	Factor float64
+	Of     string
+	Base   int
+}{
+	{Symbol: "kg", Factor: 1, Base: dimMass},
+	{Symbol: "m", Factor: 1, Base: dimLength},
+	{Symbol: "s", Factor: 1, Base: dimTime},
+	{Symbol: "A", Factor: 1, Base: dimCurrent},
+	{Symbol: "K", Factor: 1, Base: dimTemperature},
+	{Symbol: "mol", Factor: 1, Base: dimAmount},
+	{Symbol: "cd", Factor: 1, Base: dimLuminosity},
+	{Symbol: "bit", Factor: 1, Base: dimInformation},
+
+	// Mass, length, time and volume beyond the unit table
+	{Symbol: "g", Factor: 1e-3, Of: "kg"},
+	{Symbol: "t", Factor: 1000, Of: "kg"},
+	{Symbol: "lb", Factor: 0.45359237, Of: "kg"},
+	{Symbol: "nmi", Factor: 1852, Of: "m"},
+	{Symbol: "mL", Factor: 1e-6, Of: "m^3"},
+	{Symbol: "L", Factor: 1e-3, Of: "m^3"},
+	{Symbol: "gal", Factor: 3.785411784e-3, Of: "m^3"},
+
+	// Mechanics
+	{Symbol: "N", Factor: 1, Of: "kg*m/s^2"},
+	{Symbol: "kN", Factor: 1000, Of: "N"},
+	{Symbol: "lbf", Factor: 4.4482216152605, Of: "N"},
+	{Symbol: "dyn", Factor: 1e-5, Of: "N"},
+	{Symbol: "J", Factor: 1, Of: "N*m"},
+	{Symbol: "kJ", Factor: 1e3, Of: "J"},
+	{Symbol: "MJ", Factor: 1e6, Of: "J"},
+	{Symbol: "cal", Factor: 4.184, Of: "J"},
+	{Symbol: "kcal", Factor: 4184, Of: "J"},
+	{Symbol: "BTU", Factor: 1055.05585262, Of: "J"},
+	{Symbol: "eV", Factor: 1.602176634e-19, Of: "J"},
+	{Symbol: "W", Factor: 1, Of: "J/s"},
+	{Symbol: "kW", Factor: 1e3, Of: "W"},
+	{Symbol: "MW", Factor: 1e6, Of: "W"},
+	{Symbol: "hp", Factor: 745.69987158227022, Of: "W"},
+	{Symbol: "Wh", Factor: 3600, Of: "J"},
+	{Symbol: "kWh", Factor: 3.6e6, Of: "J"},
+	{Symbol: "Pa", Factor: 1, Of: "N/m^2"},
+	{Symbol: "kPa", Factor: 1e3, Of: "Pa"},
+	{Symbol: "bar", Factor: 1e5, Of: "Pa"},
+	{Symbol: "atm", Factor: 101325, Of: "Pa"},
+	{Symbol: "psi", Factor: 6894.757293168, Of: "Pa"},
+	{Symbol: "mmHg", Factor: 133.322387415, Of: "Pa"},
+	{Symbol: "Hz", Factor: 1, Of: "1/s"},
+	{Symbol: "kHz", Factor: 1e3, Of: "Hz"},
+	{Symbol: "MHz", Factor: 1e6, Of: "Hz"},
+	{Symbol: "GHz", Factor: 1e9, Of: "Hz"},
+	{Symbol: "rpm", Factor: 1.0 / 60, Of: "1/s"},
+
+	// Electricity. C is left to Celsius, so charge is given in ampere-hours.
+	{Symbol: "V", Factor: 1, Of: "W/A"},
+	{Symbol: "Ah", Factor: 1, Of: "A*h"},
+	{Symbol: "mAh", Factor: 1e-3, Of: "A*h"},
+
+	// Information, decimal multiples; the binary ones are KiB, MiB...
+	{Symbol: "B", Factor: 8, Of: "bit"},
+	{Symbol: "kB", Factor: 1e3, Of: "B"},
+	{Symbol: "MB", Factor: 1e6, Of: "B"},
+	{Symbol: "GB", Factor: 1e9, Of: "B"},
+	{Symbol: "TB", Factor: 1e12, Of: "B"},
+	{Symbol: "PB", Factor: 1e15, Of: "B"},
+	{Symbol: "KiB", Factor: 1 << 10, Of: "B"},
+	{Symbol: "MiB", Factor: 1 << 20, Of: "B"},
+	{Symbol: "GiB", Factor: 1 << 30, Of: "B"},
+	{Symbol: "TiB", Factor: 1 << 40, Of: "B"},
+	{Symbol: "PiB", Factor: 1 << 50, Of: "B"},
+	{Symbol: "kbit", Factor: 1e3, Of: "bit"},
+	{Symbol: "Mbit", Factor: 1e6, Of: "bit"},
+	{Symbol: "Gbit", Factor: 1e9, Of: "bit"},
+	{Symbol: "bps", Factor: 1, Of: "bit/s"},
+	{Symbol: "kbps", Factor: 1e3, Of: "bit/s"},
+	{Symbol: "Mbps", Factor: 1e6, Of: "bit/s"},
+	{Symbol: "Gbps", Factor: 1e9, Of: "bit/s"},
+
+	// Fuel economy, US gallons
+	{Symbol: "mpg", Factor: 1, Of: "mi/gal"},
+}
+
+var dimensionalIndex = buildDimensionalIndex()
+
+// dimensionalSymbols lists the symbols of dimensionalUnits in order.
+func dimensionalSymbols() []string {
+	names := make([]string, len(dimensionalUnits))
+	for i, u := range dimensionalUnits {
+		names[i] = u.Symbol
+	}
+	return names
+}
+
+func buildDimensionalIndex() map[string]quantity {
+	index := map[string]quantity{}
+	for _, u := range dimensionalUnits {
+		var q quantity
+		if u.Of == "" {
+			q = quantity{Factor: u.Factor}
+			q.Dim[u.Base] = 1
+		} else {
+			of, err := parseUnitExprWith(u.Of, index)
+			if err != nil {
+				panic(fmt.Sprintf("unit %s: %v", u.Symbol, err))
+			}
+			q = quantity{Factor: u.Factor * of.Factor, Dim: of.Dim}
+		}
+		index[u.Symbol] = q
+	}
+	return index
+}
+
+// derivedQuantities name common dimensions and list the units a value of
+// that dimension is converted to. Torque shares the dimension of energy.
+var derivedQuantities = []struct {
+	Name  string
+	Units []string
+}{
+	{"length", []string{"m", "km", "mm", "µm", "nm", "mi", "ft", "in", "nmi"}},
+	{"weight", []string{"kg", "g", "mg", "t", "lb", "oz"}},
+	{"duration", []string{"s", "ms", "µs", "ns", "min", "h", "d"}},
+	{"current", []string{"A", "mA", "µA"}},
+	{"force", []string{"N", "kN", "lbf", "dyn"}},
+	{"energy", []string{"J", "kJ", "MJ", "Wh", "kWh", "cal", "kcal", "BTU", "eV", "N·m"}},
+	{"power", []string{"W", "kW", "MW", "hp"}},
+	{"pressure", []string{"Pa", "kPa", "bar", "atm", "psi", "mmHg"}},
+	{"density", []string{"kg/m³", "g/cm³", "g/mL", "lb/ft³"}},
+	{"acceleration", []string{"m/s²", "ft/s²"}},
+	{"frequency", []string{"Hz", "kHz", "MHz", "GHz", "rpm"}},
+	{"digital", []string{"bit", "B", "kB", "MB", "GB", "TB", "PB", "KiB", "MiB", "GiB", "TiB", "PiB"}},
+	{"data_rate", []string{"bit/s", "kbps", "Mbps", "Gbps", "B/s", "kB/s", "MB/s", "GB/s"}},
+	{"fuel_economy", []string{"mpg", "km/L", "L/100km"}},
+	{"charge", []string{"Ah", "mAh", "A·s"}},
+	{"voltage", []string{"V"}},
+	{"speed", []string{"m/s", "km/h", "mph", "knots", "ft/s"}},
+	{"area", []string{"m²", "cm²", "km²", "ft²", "acres", "hectares"}},
+	{"volume", []string{"m³", "L", "mL", "gal", "ft³"}},
+}
+
+// findDerivedQuantity names the quantity of dimension d, if it is one of
+// derivedQuantities or, failing that, the inverse of one.
+func findDerivedQuantity(d dimension) (name string, units []string, ok bool) {
+	for _, want := range []dimension{d, d.inverse()} {
+		for _, dq := range derivedQuantities {
+			q, err := parseUnitExpr(dq.Units[0])
+			if err == nil && q.Dim == want {
+				return dq.Name, dq.Units, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// convertQuantity converts v in from into to. Reciprocal dimensions, as
+// between mpg and L/100km, convert through 1/v.
+func convertQuantity(v float64, from, to quantity) (float64, bool) {
+	switch {
+	case from.Dim == to.Dim:
+		return v * from.Factor / to.Factor, true
+	case from.Dim == to.Dim.inverse() && v != 0:
+		return 1 / (v * from.Factor * to.Factor), true
+	}
+	return 0, false
+}
+
+// toolConvertDimensional converts val in the compound unit expression
+// unit to every unit of the same quantity.
+func toolConvertDimensional(val float64, unit string, q quantity) (interface{}, error) {
+	name, units, ok := findDerivedQuantity(q.Dim)
+	conversions := map[string]interface{}{}
+	if !ok {
+		name = "derived"
+		conversions[q.Dim.String()] = roundSig(val*q.Factor, conversionDigits)
+	}
+	for _, u := range units {
+		tq, _ := parseUnitExpr(u)
+		if v, ok := convertQuantity(val, q, tq); ok {
+			conversions[u] = roundSig(v, conversionDigits)
+		}
+	}
+	if err := checkFinite(strconv.FormatFloat(val, 'g', -1, 64)+" "+unit, conversionValues(conversions)...); err != nil {
+		return nil, err
+	}
+	return &UnitConversion{
+		Type:        "unit_conversion",
+		Category:    name,
+		Dimension:   q.Dim.String(),
+		Input:       UnitValue{Val: val, Unit: unit},
+		Conversions: conversions,
+	}, nil
+}
+
+// isDimensionalUnit reports whether unit should be converted by
+// dimensional analysis rather than the unit table: it is an expression
+// with operators or exponents, or an SI symbol whose meaning differs from
+// the table's case-insensitive spelling (W is watt, not week; Mm is
+// megameter, not millimeter).
+func isDimensionalUnit(unit string) bool {
+	unit = strings.TrimSpace(unit)
+	if strings.ContainsAny(unit, "*·⋅×^()"+superscriptDigits+"⁻") || strings.Contains(unit, " per ") {
+		return true
+	}
+	if strings.Contains(unit, "/") {
+		_, legacy := lookupUnit(unit)
+		return !legacy
+	}
+	q, ok := dimensionalIndex[unit]
+	if !ok {
+		if q, ok = prefixedQuantity(unit, dimensionalIndex); !ok {
+			return false
+		}
+	}
+	legacy, ok := legacyQuantity(unit)
+	return !ok || legacy.Dim != q.Dim || math.Abs(legacy.Factor/q.Factor-1) > 1e-9
+}
+
+// parseUnitExpr parses a unit expression such as "kg*m/s^2", "N·m",
+// "g/cm³", "L/100km" or "miles per gallon". Products bind tighter than
+// division, so a/b*c is a/(b·c).
+func parseUnitExpr(expr string) (quantity, error) {
+	return parseUnitExprWith(expr, dimensionalIndex)
+}
+
+func parseUnitExprWith(expr string, symbols map[string]quantity) (quantity, error) {
+	toks, err := tokenizeUnitExpr(expr)
+	if err != nil {
+		return quantity{}, err
+	}
+	if len(toks) == 0 {
+		return quantity{}, fmt.Errorf("empty unit expression")
+	}
+	p := &unitExprParser{toks: toks, symbols: symbols}
+	q, err := p.expr()
+	if err != nil {
+		return quantity{}, err
+	}
+	if p.pos < len(p.toks) {
+		return quantity{}, fmt.Errorf("unexpected %q in unit expression %q", p.toks[p.pos].text, expr)
+	}
+	return q, nil
+}
+
+type unitToken struct {
+	kind byte // 'n' number, 'u' unit symbol, 'e' exponent, or the operator itself
+	text string
+	num  float64
+	exp  int
+}
+
+func tokenizeUnitExpr(s string) ([]unitToken, error) {
+	var toks []unitToken
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '*' || r == '·' || r == '⋅' || r == '×':
+			toks = append(toks, unitToken{kind: '*', text: string(r)})
+			i++
+		case r == '/' || r == '^' || r == '(' || r == ')' || r == '-':
+			toks = append(toks, unitToken{kind: byte(r), text: string(r)})
+			i++
+		case strings.ContainsRune(superscriptDigits+"⁻", r):
+			j := i
+			for j < len(rs) && strings.ContainsRune(superscriptDigits+"⁻", rs[j]) {
+				j++
+			}
+			exp, err := parseSuperscript(string(rs[i:j]))
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, unitToken{kind: 'e', text: string(rs[i:j]), exp: exp})
+			i = j
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(string(rs[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad number %q in unit expression", string(rs[i:j]))
+			}
+			toks = append(toks, unitToken{kind: 'n', text: string(rs[i:j]), num: n})
+			i = j
+		case unicode.IsLetter(r) || r == '°' || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || rs[j] == '°' || rs[j] == '_') {
+				j++
+			}
+			word := string(rs[i:j])
+			i = j
+			if strings.EqualFold(word, "per") {
+				toks = append(toks, unitToken{kind: '/', text: word})
+				continue
+			}
+			toks = append(toks, unitToken{kind: 'u', text: word})
+			// Digits glued to a symbol are an exponent, as in m2 or cm3
+			k := i
+			for k < len(rs) && unicode.IsDigit(rs[k]) {
+				k++
+			}
+			if k > i && (k == len(rs) || rs[k] != '.') {
+				exp, _ := strconv.Atoi(string(rs[i:k]))
+				toks = append(toks, unitToken{kind: 'e', text: string(rs[i:k]), exp: exp})
+				i = k
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in unit expression", string(r))
+		}
+	}
+	return toks, nil
+}
+
+func parseSuperscript(s string) (int, error) {
+	digits := []rune(superscriptDigits)
+	var b strings.Builder
+	for _, r := range s {
+		if r == '⁻' {
+			b.WriteByte('-')
+			continue
+		}
+		for i, d := range digits {
+			if r == d {
+				b.WriteByte(byte('0' + i))
+			}
+		}
+	}
+	n, err := strconv.Atoi(b.String())
+	if err != nil {
+		return 0, fmt.Errorf("bad exponent %q in unit expression", s)
+	}
+	return n, nil
+}
+
+type unitExprParser struct {
+	toks    []unitToken
+	pos     int
+	symbols map[string]quantity
+}
+
+func (p *unitExprParser) peek() byte {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos].kind
+	}
+	return 0
+}
+
+// expr := product ('/' product)*
+func (p *unitExprParser) expr() (quantity, error) {
+	q, err := p.product()
+	if err != nil {
+		return q, err
+	}
+	for p.peek() == '/' {
+		p.pos++
+		d, err := p.product()
+		if err != nil {
+			return q, err
+		}
+		q = q.times(d, -1)
+	}
+	return q, nil
+}
+
+// product := power (['*'] power)*
+func (p *unitExprParser) product() (quantity, error) {
+	q, err := p.power()
+	if err != nil {
+		return q, err
+	}
+	for {
+		switch p.peek() {
+		case '*':
+			p.pos++
+		case 'n', 'u', '(':
+		default:
+			return q, nil
+		}
+		f, err := p.power()
+		if err != nil {
+			return q, err
+		}
+		q = q.times(f, 1)
+	}
+}
+
+// power := atom (exponent | '^' ['-'] number)?
+func (p *unitExprParser) power() (quantity, error) {
+	q, err := p.atom()
+	if err != nil {
+		return q, err
+	}
+	switch p.peek() {
+	case 'e':
+		q = q.pow(p.toks[p.pos].exp)
+		p.pos++
+	case '^':
+		p.pos++
+		sign := 1
+		if p.peek() == '-' {
+			sign = -1
+			p.pos++
+		}
+		if p.peek() != 'n' || p.toks[p.pos].num != math.Trunc(p.toks[p.pos].num) {
+			return q, fmt.Errorf("exponent after ^ must be an integer")
+		}
+		q = q.pow(sign * int(p.toks[p.pos].num))
+		p.pos++
+	}
+	return q, nil
+}
+
+// atom := number | symbol | '(' expr ')'
+func (p *unitExprParser) atom() (quantity, error) {
+	if p.pos >= len(p.toks) {
+		return quantity{}, fmt.Errorf("unit expression ends early")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	switch t.kind {
+	case 'n':
+		return quantity{Factor: t.num}, nil
+	case 'u':
+		q, ok := p.lookup(t.text)
+		if !ok {
+			return q, toolErrorf(KindUnsupportedUnit, "Unknown unit %q", t.text)
+		}
+		return q, nil
+	case '(':
+		q, err := p.expr()
+		if err != nil {
+			return q, err
+		}
+		if p.peek() != ')' {
+			return q, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return q, nil
+	}
+	return quantity{}, fmt.Errorf("unexpected %q in unit expression", t.text)
+}
+
+// lookup resolves a symbol: exact SI symbols first, then prefixed ones,
+// then the unit table's case-insensitive spellings, then SI symbols
+// ignoring case if that is unambiguous.
+func (p *unitExprParser) lookup(sym string) (quantity, bool) {
+	if q, ok := p.symbols[sym]; ok {
+		return q, true
+	}
+	if q, ok := prefixedQuantity(sym, p.symbols); ok {
+		return q, true
+	}
+	if q, ok := legacyQuantity(sym); ok {
+		return q, true
+	}
+	var found []quantity
+	for s, q := range p.symbols {
+		if strings.EqualFold(s, sym) {
+			found = append(found, q)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	return quantity{}, false
+}
+
+// legacyQuantity expresses a unit from unitTable in SI. Temperatures
+// count as intervals, so only their scale matters.
+func legacyQuantity(sym string) (quantity, bool) {
+	u, ok := lookupUnit(sym)
+	if !ok {
+		return quantity{}, false
+	}
+	var q quantity
+	switch u.Category {
+	case "length":
+		q = quantity{Factor: u.Factor}
+		q.Dim[dimLength] = 1
+	case "weight":
+		q = quantity{Factor: u.Factor}
+		q.Dim[dimMass] = 1
+	case "duration":
+		q = quantity{Factor: u.Factor / 1000}
+		q.Dim[dimTime] = 1
+	case "temperature":
+		q = quantity{Factor: u.Factor}
+		q.Dim[dimTemperature] = 1
+	case "digital":
+		q = quantity{Factor: u.Factor * 8}
+		q.Dim[dimInformation] = 1
+	case "speed":
+		q = quantity{Factor: u.Factor}
+		q.Dim[dimLength], q.Dim[dimTime] = 1, -1
+	case "area":
+		q = quantity{Factor: u.Factor}
+		q.Dim[dimLength] = 2
+	case "volume":
+		q = quantity{Factor: u.Factor * 1e-6}
+		q.Dim[dimLength] = 3
+	default:
+		return quantity{}, false
+	}
+	return q, true
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Durations ---
+//
+// Duration mode reads ISO 8601 durations (P1DT2H30M), Go duration strings
+// (1h30m) and phrases (2 days 3 hours), adds and subtracts them, and
+// renders the sum in each notation. A day is 24 hours; months and years
+// have no fixed length and are refused.
+
+// DurationConversion is the result of duration mode.
+type DurationConversion struct {
+	Type        string             `json:"type"` // always "duration"
+	Original    string             `json:"original"`
+	Terms       []DurationTerm     `json:"terms"`
+	Nanoseconds int64              `json:"nanoseconds"`
+	Formats     map[string]string  `json:"formats"` // iso8601, go, clock, human
+	Totals      map[string]float64 `json:"totals"`  // the duration in each unit
+}
+
+// DurationTerm is one operand of a duration expression.
+type DurationTerm struct {
+	Op       string `json:"op"` // "+" or "-"
+	Text     string `json:"text"`
+	Notation string `json:"notation"` // "iso8601", "go" or "human"
+	Go       string `json:"go"`
+}
+
+const durationConversionSchema = `{
+	"type": "object",
+	"properties": {
+		"type": {"const": "duration"},
+		"original": {"type": "string"},
+		"terms": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"op": {"enum": ["+", "-"]},
+					"text": {"type": "string"},
+					"notation": {"enum": ["iso8601", "go", "human"]},
+					"go": {"type": "string"}
+				},
+				"required": ["op", "text", "notation", "go"]
+			}
+		},
+		"nanoseconds": {"type": "integer"},
+		"formats": {"type": "object", "additionalProperties": {"type": "string"}},
+		"totals": {"type": "object", "additionalProperties": {"type": "number"}}
+	},
+	"required": ["type", "original", "terms", "nanoseconds", "formats", "totals"]
+}`
+
+// durationUnits maps the unit spellings of Go strings and phrases,
+// singular, to their length.
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond, "nanosecond": time.Nanosecond,
+	"us": time.Microsecond, "µs": time.Microsecond, "μs": time.Microsecond, "microsecond": time.Microsecond,
+	"ms": time.Millisecond, "millisecond": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "wk": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour,
+	"fortnight": 14 * 24 * time.Hour,
+}
+
+var (
+	isoDuration      = regexp.MustCompile(`^P(?:([\d.,]+)Y)?(?:([\d.,]+)M)?(?:([\d.,]+)W)?(?:([\d.,]+)D)?(?:T(?:([\d.,]+)H)?(?:([\d.,]+)M)?(?:([\d.,]+)S)?)?$`)
+	durationPart     = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)\s*([a-zµμ]+)`)
+	durationOp       = regexp.MustCompile(`\s*([+-])\s*`)
+	durationUnitText = regexp.MustCompile(`[a-zµμ]+`)
+)
+
+// parseDurationExpr parses terms joined by + and -, or "plus" and
+// "minus", and returns them with their sum.
+func parseDurationExpr(input string) ([]DurationTerm, time.Duration, error) {
+	s := strings.TrimSpace(input)
+	s = strings.NewReplacer(" plus ", " + ", " minus ", " - ").Replace(s)
+	if s == "" {
+		return nil, 0, fmt.Errorf("empty duration")
+	}
+	var terms []DurationTerm
+	var total time.Duration
+	op := "+"
+	if s[0] == '+' || s[0] == '-' {
+		op, s = s[:1], s[1:]
+	}
+	for {
+		text := s
+		next := ""
+		if loc := durationOp.FindStringSubmatchIndex(s); loc != nil {
+			text, next, s = s[:loc[0]], s[loc[2]:loc[3]], s[loc[1]:]
+		}
+		text = strings.TrimSpace(text)
+		d, notation, err := parseDurationTerm(text)
+		if err != nil {
+			return nil, 0, err
+		}
+		ok := true
+		if op == "-" {
+			total, ok = addDurations(total, -d)
+		} else {
+			total, ok = addDurations(total, d)
+		}
+		if !ok {
+			return nil, 0, durationRangeError(input)
+		}
+		terms = append(terms, DurationTerm{Op: op, Text: text, Notation: notation, Go: d.String()})
+		if next == "" {
+			return terms, total, nil
+		}
+		op = next
+	}
+}
+
+// parseDurationTerm parses one ISO 8601 duration, Go duration string or
+// phrase.
+func parseDurationTerm(text string) (time.Duration, string, error) {
+	if text == "" {
+		return 0, "", fmt.Errorf("missing duration around + or -")
+	}
+	if m := isoDuration.FindStringSubmatch(strings.ToUpper(text)); m != nil && text != "P" && !strings.HasSuffix(strings.ToUpper(text), "T") {
+		if strings.Trim(m[1]+m[2], "0.,") != "" {
+			return 0, "", fmt.Errorf("%q has years or months, which have no fixed length; use weeks or days, or a relative time such as 'in 1 month'", text)
+		}
+		var total time.Duration
+		for i, unit := range []time.Duration{0, 0, 7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+			if m[i+1] == "" {
+				continue
+			}
+			num := strings.Replace(m[i+1], ",", ".", 1)
+			if _, err := strconv.ParseFloat(num, 64); err != nil {
+				return 0, "", fmt.Errorf("%q is not an ISO 8601 duration", text)
+			}
+			if unit == 0 {
+				continue // years and months, zero by now
+			}
+			d, ok := scaleDuration(num, unit)
+			if ok {
+				total, ok = addDurations(total, d)
+			}
+			if !ok {
+				return 0, "", durationRangeError(text)
+			}
+		}
+		return total, "iso8601", nil
+	}
+
+	notation := "human"
+	if _, err := time.ParseDuration(text); err == nil {
+		notation = "go"
+	}
+	s := strings.ToLower(text)
+	var total time.Duration
+	parts := 0
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if rest, ok := strings.CutPrefix(s, "and "); ok && parts > 0 {
+			s = strings.TrimLeft(rest, " ")
+		}
+		if s == "" {
+			break
+		}
+		m := durationPart.FindStringSubmatch(s)
+		if m == nil {
+			return 0, "", fmt.Errorf("%q is not a duration; use ISO 8601 (P1DT2H), Go (1h30m) or words (2 hours 30 minutes)", text)
+		}
+		unit, ok := durationUnits[m[2]]
+		if !ok {
+			unit, ok = durationUnits[strings.TrimSuffix(m[2], "s")]
+		}
+		if !ok {
+			return 0, "", fmt.Errorf("unknown duration unit %q in %q", m[2], text)
+		}
+		d, ok := scaleDuration(m[1], unit)
+		if ok {
+			total, ok = addDurations(total, d)
+		}
+		if !ok {
+			return 0, "", durationRangeError(text)
+		}
+		parts++
+		s = s[len(m[0]):]
+	}
+	if parts == 0 {
+		return 0, "", fmt.Errorf("%q is not a duration", text)
+	}
+	return total, notation, nil
+}
+
+// scaleDuration returns the decimal number num of unit, exact for the
+// whole part. ok is false when the result does not fit a time.Duration.
+func scaleDuration(num string, unit time.Duration) (d time.Duration, ok bool) {
+	intPart, frac, _ := strings.Cut(num, ".")
+	if intPart != "" {
+		whole, err := strconv.ParseInt(intPart, 10, 64)
+		if err != nil || whole > math.MaxInt64/int64(unit) {
+			return 0, false
+		}
+		d = time.Duration(whole) * unit
+	}
+	if frac != "" {
+		f, _ := strconv.ParseFloat("0."+frac, 64)
+		return addDurations(d, time.Duration(math.Round(f*float64(unit))))
+	}
+	return d, true
+}
+
+// addDurations returns a+b, or false if the sum overflows.
+func addDurations(a, b time.Duration) (time.Duration, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+func durationRangeError(s string) error {
+	return fmt.Errorf("%q is out of range: durations reach about 292 years", s)
+}
+
+// isDurationExpr reports whether a value without unit reads as a
+// duration rather than a quantity: an ISO 8601 duration, a Go string of
+// several units such as 1h30m, or a sum of durations. A single "30m" or
+// "2 hours" stays a quantity. An ISO 8601 duration that fails, such as
+// P1M, is still one so that the error says why.
+func isDurationExpr(s string) bool {
+	terms, _, err := parseDurationExpr(s)
+	if err != nil {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		return len(s) > 2 && isoDuration.MatchString(strings.TrimPrefix(s, "-"))
+	}
+	if len(terms) > 1 {
+		return true
+	}
+	t := terms[0]
+	return t.Notation == "iso8601" || t.Notation == "go" && len(durationUnitText.FindAllString(t.Text, -1)) > 1
+}
+
+// toolConvertDuration evaluates a duration expression and renders the
+// result in every notation.
+func toolConvertDuration(input string) (*DurationConversion, error) {
+	terms, d, err := parseDurationExpr(input)
+	if err != nil {
+		return nil, toolErrorf(KindParseFailure, "Could not parse duration: %v", err)
+	}
+	return &DurationConversion{
+		Type:        "duration",
+		Original:    input,
+		Terms:       terms,
+		Nanoseconds: int64(d),
+		Formats: map[string]string{
+			"iso8601": formatISODuration(d),
+			"go":      d.String(),
+			"clock":   formatClockDuration(d),
+			"human":   formatHumanDuration(d),
+		},
+		Totals: map[string]float64{
+			"weeks":   roundSig(d.Hours()/(7*24), conversionDigits),
+			"days":    roundSig(d.Hours()/24, conversionDigits),
+			"hours":   roundSig(d.Hours(), conversionDigits),
+			"minutes": roundSig(d.Minutes(), conversionDigits),
+			"seconds": roundSig(d.Seconds(), conversionDigits),
+			"ms":      roundSig(float64(d)/float64(time.Millisecond), conversionDigits),
+		},
+	}, nil
+}
+
+// splitDuration splits the magnitude of d into days, hours, minutes,
+// seconds and nanoseconds.
+func splitDuration(d time.Duration) (neg bool, days, hours, minutes, seconds, nanos int64) {
+	u := uint64(d)
+	if d < 0 {
+		neg, u = true, uint64(-d) // -MinInt64 wraps to itself, which uint64 reads right
+	}
+	nanos = int64(u % uint64(time.Second))
+	secs := int64(u / uint64(time.Second))
+	return neg, secs / 86400, secs / 3600 % 24, secs / 60 % 60, secs % 60, nanos
+}
+
+// formatISODuration renders d as an ISO 8601 duration such as P1DT2H30M.
+func formatISODuration(d time.Duration) string {
+	neg, days, hours, minutes, seconds, nanos := splitDuration(d)
+	var b strings.Builder
+	if neg {
+		b.WriteByte('-')
+	}
+	b.WriteByte('P')
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || nanos > 0 || days == 0 {
+		b.WriteByte('T')
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 || nanos > 0 || hours == 0 && minutes == 0 {
+			b.WriteString(strconv.FormatInt(seconds, 10) + fractionDigits(nanos) + "S")
+		}
+	}
+	return b.String()
+}
+
+// formatClockDuration renders d as hh:mm:ss, with hours past 24 and
+// fractional seconds if any.
+func formatClockDuration(d time.Duration) string {
+	neg, days, hours, minutes, seconds, nanos := splitDuration(d)
+	sign := ""
+	if neg {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d%s", sign, days*24+hours, minutes, seconds, fractionDigits(nanos))
+}
+
+// fractionDigits renders nanoseconds as a decimal fraction of a second
+// without trailing zeros, or "" for none.
+func fractionDigits(nanos int64) string {
+	if nanos == 0 {
+		return ""
+	}
+	return "." + strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")
+}
+
+// formatHumanDuration renders d in words, such as "2 days 3 hours".
+func formatHumanDuration(d time.Duration) string {
+	neg, days, hours, minutes, seconds, nanos := splitDuration(d)
+	var parts []string
+	add := func(n int64, unit string) {
+		if n == 1 {
+			parts = append(parts, "1 "+unit)
+		} else if n != 0 {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, unit))
+		}
+	}
+	add(days, "day")
+	add(hours, "hour")
+	add(minutes, "minute")
+	add(seconds, "second")
+	add(nanos/1e6, "millisecond")
+	add(nanos/1e3%1e3, "microsecond")
+	add(nanos%1e3, "nanosecond")
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+	if neg {
+		return "minus " + strings.Join(parts, " ")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDurationExpr(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"P1DT2H30M", 26*time.Hour + 30*time.Minute},
+		{"PT0.3S", 300 * time.Millisecond},
+		{"P1.5DT2,5H", 38*time.Hour + 30*time.Minute},
+		{"P2W", 14 * 24 * time.Hour},
+		{"P0Y1D", 24 * time.Hour},
+		{"P0M", 0},
+		{"P0Y0MT1M", time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"1h30m + 45m", 135 * time.Minute},
+		{"2 days 3 hours", 51 * time.Hour},
+		{"1 hour, 30 minutes and 15 seconds", time.Hour + 30*time.Minute + 15*time.Second},
+		{"1 fortnight minus 1 week", 7 * 24 * time.Hour},
+		{"-1h + 30m", -30 * time.Minute},
+		{"1.5 hours", 90 * time.Minute},
+		{"9007199254740993ns + 1ns", 9007199254740994}, // past 2^53, still exact
+		{"2562047h", 2562047 * time.Hour},
+		{"-2562047h - 47m", -2562047*time.Hour - 47*time.Minute},
+	} {
+		_, got, err := parseDurationExpr(tc.in)
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseDurationExprErrors(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"P1M", "no fixed length"},
+		{"P1Y2D", "no fixed length"},
+		{"P1.2.3D", "not an ISO 8601 duration"},
+		{"2 parsecs", "unknown duration unit"},
+		{"1h +", "missing duration"},
+		{"106752 days", "out of range"},
+		{"2562047h + 2562047h", "out of range"},
+		{"-2562047h - 2562047h", "out of range"},
+		{"99999999999999999999ns", "out of range"},
+		{"PT9999999999999H", "out of range"},
+	} {
+		_, _, err := parseDurationExpr(tc.in)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: error %v, want one containing %q", tc.in, err, tc.want)
+		}
+	}
+}
+
+func TestIsDurationExpr(t *testing.T) {
+	for in, want := range map[string]bool{
+		"P1DT2H":      true,
+		"P1M":         true, // so the error explains months
+		"1h30m":       true,
+		"30m + 1h":    true,
+		"30m":         false,
+		"2 hours":     false,
+		"10 km":       false,
+		"2024-01-02":  false,
+		"in 2 hours":  false,
+		"1 day 2 hrs": false,
+	} {
+		if got := isDurationExpr(in); got != want {
+			t.Errorf("isDurationExpr(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for _, tc := range []struct {
+		d                 time.Duration
+		iso, clock, human string
+	}{
+		{0, "PT0S", "00:00:00", "0 seconds"},
+		{26*time.Hour + 30*time.Minute, "P1DT2H30M", "26:30:00", "1 day 2 hours 30 minutes"},
+		{-90 * time.Second, "-PT1M30S", "-00:01:30", "minus 1 minute 30 seconds"},
+		{1500 * time.Millisecond, "PT1.5S", "00:00:01.5", "1 second 500 milliseconds"},
+		{48 * time.Hour, "P2D", "48:00:00", "2 days"},
+		{math.MinInt64, "-P106751DT23H47M16.854775808S", "-2562047:47:16.854775808", "minus 106751 days 23 hours 47 minutes 16 seconds 854 milliseconds 775 microseconds 808 nanoseconds"},
+	} {
+		if got := formatISODuration(tc.d); got != tc.iso {
+			t.Errorf("formatISODuration(%v) = %s, want %s", tc.d, got, tc.iso)
+		}
+		if got := formatClockDuration(tc.d); got != tc.clock {
+			t.Errorf("formatClockDuration(%v) = %s, want %s", tc.d, got, tc.clock)
+		}
+		if got := formatHumanDuration(tc.d); got != tc.human {
+			t.Errorf("formatHumanDuration(%v) = %s, want %s", tc.d, got, tc.human)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// --- Tool Errors ---
+
+// ErrorKind categorizes why a tool call failed, so clients can react to
+// the category rather than parsing the message.
+type ErrorKind string
+
+const (
+	KindInvalidInput    ErrorKind = "invalid_input"    // arguments are well-typed but meaningless
+	KindUnsupportedUnit ErrorKind = "unsupported_unit" // unit or category the converter does not know
+	KindParseFailure    ErrorKind = "parse_failure"    // value could not be parsed as time, color, token...
+	KindTimeout         ErrorKind = "timeout"          // call exceeded its deadline
+	KindInternal        ErrorKind = "internal"         // bug in the tool, e.g. a recovered panic
+)
+
+// ToolError is the error returned by tool implementations. It is reported
+// to the client as an isError result rather than a JSON-RPC error.
+type ToolError struct {
+	Kind    ErrorKind
+	Message string
+}
+
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// logLevel is the level at which the error is logged to the client.
+func (e *ToolError) logLevel() string {
+	switch e.Kind {
+	case KindInternal:
+		return "error"
+	case KindTimeout:
+		return "warning"
+	}
+	return "notice"
+}
+
+func toolErrorf(kind ErrorKind, format string, args ...interface{}) *ToolError {
+	return &ToolError{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// asToolError wraps errors that did not come from a tool, such as a
+// cancelled context, as internal tool errors.
+func asToolError(err error) *ToolError {
+	if te, ok := err.(*ToolError); ok {
+		return te
+	}
+	return &ToolError{Kind: KindInternal, Message: err.Error()}
+}
+
+// recoverToolPanic turns a panic in tool name into an internal ToolError
+// stored in *errp, logging the stack to stderr. Use it as
+// defer recoverToolPanic(name, &err).
+func recoverToolPanic(name string, errp *error) {
+	if r := recover(); r != nil {
+		fmt.Fprintf(os.Stderr, "omni-tool: panic in tool %s: %v\n%s", name, r, debug.Stack())
+		*errp = toolErrorf(KindInternal, "Internal error in %s: %v", name, r)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- Exact Arithmetic ---
+//
+// Crypto amounts and byte counts are integers at heart, and float64 loses
+// them: 123456789012345678901 wei does not survive a round trip. Units of
+// exact categories convert with big.Rat instead and report every value as
+// an exact decimal string.
+
+// cryptoTokens are the tokens besides BTC and ETH. Each has a unit for
+// whole tokens and a _raw unit for the integer amount stored on chain,
+// 10^-Decimals of a token. convert's decimals argument overrides Decimals,
+// so erc20 stands for any ERC-20 token.
+var cryptoTokens = []struct {
+	Symbol   string
+	Decimals int
+}{
+	{"usdc", 6},
+	{"usdt", 6},
+	{"dai", 18},
+	{"erc20", 18},
+}
+
+// tokenUnits returns the unit table rows of cryptoTokens.
+func tokenUnits() []unitDef {
+	var units []unitDef
+	for _, t := range cryptoTokens {
+		units = append(units,
+			unitDef{Name: t.Symbol, Category: "crypto", System: t.Symbol, Factor: 1},
+			unitDef{Name: t.Symbol + "_raw", Category: "crypto", System: t.Symbol, Factor: 1 / float64(pow10(t.Decimals).Int64()), Decimals: t.Decimals},
+		)
+	}
+	return units
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// ratFactor is u.Factor as an exact rational. Table factors are written as
+// decimals, so the shortest decimal that round-trips the float64 is the
+// intended value. decimals, if not nil, replaces the Decimals of a token's
+// raw unit.
+func (u *unitDef) ratFactor(decimals *int) *big.Rat {
+	if u.Decimals > 0 {
+		d := u.Decimals
+		if decimals != nil {
+			d = *decimals
+		}
+		return new(big.Rat).SetFrac(big.NewInt(1), pow10(d))
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(u.Factor, 'g', -1, 64))
+	return r
+}
+
+// Bounds on the values parseRat accepts. big.Rat takes any exponent, and
+// 1e-999999 has a million-digit denominator that every later step pays
+// for; no unit needs a fraction of that.
+const (
+	maxRatDigits   = 100
+	maxRatExponent = 400
+)
+
+// decimalNumber is the syntax parseRat accepts: no fractions such as 1/3,
+// no hex.
+var decimalNumber = regexp.MustCompile(`^[+-]?(\d*)\.?(\d*)(?:[eE]([+-]?\d+))?$`)
+
+// errNotDecimal is parseRat's error for text that is not a decimal number
+// at all, so callers can try other readings.
+var errNotDecimal = errors.New("not a decimal number")
+
+// parseRat parses an integer or decimal value, with or without exponent,
+// exactly. A number beyond maxRatDigits or maxRatExponent is an
+// invalid_input ToolError; anything else that is not a decimal number is
+// errNotDecimal.
+func parseRat(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	m := decimalNumber.FindStringSubmatch(s)
+	if m == nil || m[1]+m[2] == "" {
+		return nil, errNotDecimal
+	}
+	exp, err := strconv.Atoi(m[3])
+	if m[3] == "" {
+		exp, err = 0, nil
+	}
+	if len(m[1])+len(m[2]) > maxRatDigits || err != nil || exp > maxRatExponent || exp < -maxRatExponent {
+		return nil, toolErrorf(KindInvalidInput, "%.40q is out of range: numbers may have up to %d digits and an exponent up to ±%d", s, maxRatDigits, maxRatExponent)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, errNotDecimal
+	}
+	return r, nil
+}
+
+// ratRangeError returns the first of errs from parseRat that rejects a
+// number as out of range rather than as not a decimal.
+func ratRangeError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil && err != errNotDecimal {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxExactDigits bounds the decimals of a quotient that does not
+// terminate. Units of exact categories never produce one.
+const maxExactDigits = 36
+
+// ratString formats r as a decimal string without exponent, exact when r
+// has a terminating decimal expansion. Otherwise it is rounded to
+// maxExactDigits decimals and ends in "..." to say so.
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	digits, exact := decimalDigits(r.Denom())
+	if !exact {
+		return strings.TrimRight(r.FloatString(maxExactDigits), "0") + "..."
+	}
+	return r.FloatString(digits)
+}
+
+// decimalDigits returns how many decimals 1/d needs, and whether that
+// expansion terminates at all, i.e. d is 2^a * 5^b. The twos are its
+// trailing zero bits; what remains must be the power of five its bit
+// length allows.
+func decimalDigits(d *big.Int) (int, bool) {
+	twos := int(d.TrailingZeroBits())
+	odd := new(big.Int).Rsh(d, uint(twos))
+	if odd.BitLen() == 1 {
+		return twos, true
+	}
+	// 5^k has floor(k*log2(5))+1 bits
+	k := int(float64(odd.BitLen()-1) / math.Log2(5))
+	for _, fives := range []int{k, k + 1} {
+		if odd.Cmp(new(big.Int).Exp(big.NewInt(5), big.NewInt(int64(fives)), nil)) == 0 {
+			return max(twos, fives), true
+		}
+	}
+	return 0, false
+}
+
+// ratFloat approximates r for the float fields next to exact strings.
+func ratFloat(r *big.Rat) float64 {
+	f, _ := r.Float64()
+	return f
+}
+
+// checkFinite rejects a result whose float fields JSON cannot carry,
+// such as 1e400 MB or inf.
+func checkFinite(value string, vals ...float64) error {
+	for _, v := range vals {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return toolErrorf(KindInvalidInput, "%.40q is out of range: values and results must be finite and within about ±1.8e308", value)
+		}
+	}
+	return nil
+}
+
+// toolConvertExact is toolConvertUnits for exact categories: valStr is
+// parsed as a rational and every conversion is an exact decimal string.
+func toolConvertExact(valStr string, from string, cat string, decimals *int) (interface{}, error) {
+	from = strings.TrimSpace(from)
+	val, err := parseRat(valStr)
+	if err == errNotDecimal {
+		return nil, toolErrorf(KindInvalidInput, "value %q is not a number", valStr)
+	} else if err != nil {
+		return nil, err
+	}
+	unit, alt, ok := resolveUnit(from)
+	if !ok || unit.Category != cat {
+		return nil, toolErrorf(KindUnsupportedUnit, "Unknown %s unit: %s", cat, from)
+	}
+	if err := checkDecimals(decimals, unit); err != nil {
+		return nil, err
+	}
+	base := new(big.Rat).Mul(val, unit.ratFactor(decimals))
+
+	res := &UnitConversion{
+		Type:     "unit_conversion",
+		Category: cat,
+		Input:    UnitValue{Val: ratFloat(val), Unit: from, Exact: ratString(val)},
+		Conversions: unitConversions(cat, unit, func(u *unitDef) interface{} {
+			return ratString(new(big.Rat).Quo(base, u.ratFactor(decimals)))
+		}),
+	}
+	floats := []float64{res.Input.Val}
+	if alt != nil {
+		altBase := new(big.Rat).Mul(val, alt.ratFactor(decimals))
+		res.Interpretations = []UnitInterpretation{
+			{Unit: unit.Name, System: unit.System, Value: ratFloat(base), Exact: ratString(base)},
+			{Unit: alt.Name, System: alt.System, Value: ratFloat(altBase), Exact: ratString(altBase)},
+		}
+		floats = append(floats, res.Interpretations[0].Value, res.Interpretations[1].Value)
+	}
+	if err := checkFinite(valStr, floats...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// convertExact converts val from one unit of an exact category to another.
+func convertExact(val *big.Rat, from, to *unitDef, decimals *int) *big.Rat {
+	base := new(big.Rat).Mul(val, from.ratFactor(decimals))
+	return base.Quo(base, to.ratFactor(decimals))
+}
+
+// checkDecimals rejects a decimals argument for a unit it cannot apply
+// to.
+func checkDecimals(decimals *int, units ...*unitDef) error {
+	if decimals == nil {
+		return nil
+	}
+	if *decimals < 0 || *decimals > 77 {
+		return toolErrorf(KindInvalidInput, "decimals must be between 0 and 77, got %d", *decimals)
+	}
+	for _, u := range units {
+		if u.Decimals > 0 {
+			return nil
+		}
+	}
+	return toolErrorf(KindInvalidInput, "decimals applies to token amounts such as erc20_raw, not %s", units[0].Name)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseRat(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string // RatString, or "" for errNotDecimal
+	}{
+		{"42", "42"},
+		{" -1.5 ", "-3/2"},
+		{".25", "1/4"},
+		{"5.", "5"},
+		{"1e3", "1000"},
+		{"2.5E-2", "1/40"},
+		{"123456789012345678901", "123456789012345678901"},
+		{"1/3", ""},
+		{"0x10", ""},
+		{"1_000", ""},
+		{"inf", ""},
+		{"NaN", ""},
+		{"", ""},
+		{".", ""},
+		{"1e", ""},
+		{"1.2.3", ""},
+	} {
+		r, err := parseRat(tc.in)
+		switch {
+		case tc.want == "" && err != errNotDecimal:
+			t.Errorf("parseRat(%q) = %v, %v; want errNotDecimal", tc.in, r, err)
+		case tc.want != "" && err != nil:
+			t.Errorf("parseRat(%q): %v", tc.in, err)
+		case tc.want != "" && r.RatString() != tc.want:
+			t.Errorf("parseRat(%q) = %s, want %s", tc.in, r.RatString(), tc.want)
+		}
+	}
+}
+
+func TestParseRatRange(t *testing.T) {
+	for _, in := range []string{"1e-20000", "1e401", "1e-999999", "1e99999999999999999999", strings.Repeat("9", 101)} {
+		_, err := parseRat(in)
+		if te, ok := err.(*ToolError); !ok || te.Kind != KindInvalidInput {
+			t.Errorf("parseRat(%.20s...): %v, want %s", in, err, KindInvalidInput)
+		}
+	}
+	for _, in := range []string{"1e400", "1e-400", strings.Repeat("9", 100)} {
+		if _, err := parseRat(in); err != nil {
+			t.Errorf("parseRat(%.20s...): %v", in, err)
+		}
+	}
+}
+
+func TestRatString(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"7", "7"},
+		{"-1/4", "-0.25"},
+		{"1/1024", "0.0009765625"},
+		{"3/125", "0.024"},
+		{"1/1000000000000000000", "0.000000000000000001"},
+		{"1/3", "0.333333333333333333333333333333333333..."},
+		{"2/3", "0.666666666666666666666666666666666667..."},
+		{"1/6", "0.166666666666666666666666666666666667..."},
+	} {
+		r, _ := new(big.Rat).SetString(tc.in)
+		if got := ratString(r); got != tc.want {
+			t.Errorf("ratString(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestDecimalDigits(t *testing.T) {
+	for _, tc := range []struct {
+		d      *big.Int
+		digits int
+		exact  bool
+	}{
+		{big.NewInt(1), 0, true},
+		{big.NewInt(2), 1, true},
+		{big.NewInt(5), 1, true},
+		{big.NewInt(8), 3, true},
+		{big.NewInt(125), 3, true},
+		{big.NewInt(80), 4, true},
+		{pow10(400), 400, true},
+		{new(big.Int).Exp(big.NewInt(5), big.NewInt(1000), nil), 1000, true},
+		{big.NewInt(3), 0, false},
+		{big.NewInt(15), 0, false},
+		{new(big.Int).Mul(pow10(50), big.NewInt(7)), 0, false},
+	} {
+		digits, exact := decimalDigits(tc.d)
+		if exact != tc.exact || (exact && digits != tc.digits) {
+			t.Errorf("decimalDigits(%.20s) = %d, %v; want %d, %v", tc.d, digits, exact, tc.digits, tc.exact)
+		}
+	}
+}
+
+func TestConvertExact(t *testing.T) {
+	for _, tc := range []struct {
+		value, from, to, want string
+	}{
+		{"123456789012345678901", "wei", "eth", "123.456789012345678901"},
+		{"1", "eth", "wei", "1000000000000000000"},
+		{"1.5", "btc", "sat", "150000000"},
+		{"1", "GiB", "MiB", "1024"},
+		{"1", "MB", "KiB", "976.5625"},
+		{"0.1", "btc", "sat", "10000000"},
+	} {
+		from, _, _ := resolveUnit(tc.from)
+		to, _, _ := resolveUnit(tc.to)
+		val, err := parseRat(tc.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ratString(convertExact(val, from, to, nil)); got != tc.want {
+			t.Errorf("%s %s = %s %s, want %s", tc.value, tc.from, got, tc.to, tc.want)
+		}
+	}
+}
+
+func TestConvertExactRejectsNonFinite(t *testing.T) {
+	for _, tc := range []struct{ value, unit string }{{"1e400", "MB"}, {"1e400", "btc"}} {
+		_, err := toolConvertExact(tc.value, tc.unit, inferCategory(tc.unit), nil)
+		if te, ok := err.(*ToolError); !ok || te.Kind != KindInvalidInput {
+			t.Errorf("%s %s: %v, want %s", tc.value, tc.unit, err, KindInvalidInput)
+		}
+	}
+}
+
+func TestCompareValuesExactly(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, diff string
+		greater    bool
+	}{
+		{"9007199254740993", "9007199254740992", "1", true}, // equal as float64
+		{"0.1", "0.3", "-0.2", false},
+		{"1e-18", "0", "0.000000000000000001", true},
+	} {
+		res, err := toolCompareValues(context.Background(), tc.a, tc.b)
+		if err != nil {
+			t.Fatalf("%s vs %s: %v", tc.a, tc.b, err)
+		}
+		n := res.(*NumericComparison)
+		if n.ExactDiff != tc.diff || n.AGreater != tc.greater {
+			t.Errorf("%s vs %s: exact_diff %s, a_greater %v; want %s, %v", tc.a, tc.b, n.ExactDiff, n.AGreater, tc.diff, tc.greater)
+		}
+	}
+	if _, err := toolCompareValues(context.Background(), "1", "1e-20000"); err == nil {
+		t.Error("1 vs 1e-20000: no error")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// --- JSON-RPC Framing ---
+//
+// parseMessage and decodeRequest implement the JSON-RPC 2.0 rules shared
+// by both transports: invalid JSON is a -32700 parse error, a well-formed
+// value that is not a request is a -32600 invalid request, and an array
+// is a batch whose responses are returned together as an array.
+
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+)
+
+// parseMessage splits one transport message into its request objects.
+// batch reports whether the message was an array. A non-nil errResp must
+// be sent back instead of processing anything.
+func parseMessage(raw []byte) (msgs []json.RawMessage, batch bool, errResp *JSONRPCResponse) {
+	raw = bytes.TrimSpace(raw)
+	if !json.Valid(raw) {
+		return nil, false, errorResponse(nil, codeParseError, "Parse error")
+	}
+	if raw[0] != '[' {
+		return []json.RawMessage{raw}, false, nil
+	}
+	if err := json.Unmarshal(raw, &msgs); err != nil || len(msgs) == 0 {
+		return nil, true, errorResponse(nil, codeInvalidRequest, "Invalid Request: empty batch")
+	}
+	return msgs, true, nil
+}
+
+// decodeRequest validates a single request object. ok is false for
+// messages that must be skipped without a reply, such as responses the
+// client sends back; errResp is set for invalid requests.
+func decodeRequest(raw json.RawMessage) (req JSONRPCRequest, ok bool, errResp *JSONRPCResponse) {
+	var probe struct {
+		ID     json.RawMessage `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return req, false, errorResponse(nil, codeInvalidRequest, "Invalid Request: expected an object")
+	}
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return req, false, errorResponse(validID(probe.ID), codeInvalidRequest, fmt.Sprintf("Invalid Request: %v", err))
+	}
+	if req.JSONRPC != "2.0" {
+		return req, false, errorResponse(validID(probe.ID), codeInvalidRequest, `Invalid Request: jsonrpc must be "2.0"`)
+	}
+	if req.Method == "" {
+		if probe.Result != nil || probe.Error != nil {
+			// A response to a server-initiated request; nothing to answer
+			return req, false, nil
+		}
+		return req, false, errorResponse(validID(probe.ID), codeInvalidRequest, "Invalid Request: missing method")
+	}
+	switch req.ID.(type) {
+	case nil, string, float64:
+	default:
+		return req, false, errorResponse(nil, codeInvalidRequest, "Invalid Request: id must be a string or number")
+	}
+	return req, true, nil
+}
+
+// validID returns raw decoded as an id if it is a legal one, so error
+// responses echo it; otherwise the id is null.
+func validID(raw json.RawMessage) interface{} {
+	var id interface{}
+	json.Unmarshal(raw, &id)
+	switch id.(type) {
+	case string, float64:
+		return id
+	}
+	return nil
+}
+
+func errorResponse(id interface{}, code int, message string) *JSONRPCResponse {
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		Error:   &RPCError{Code: code, Message: message},
+		ID:      id,
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// --- Client Logging ---
+//
+// Implements the MCP logging capability: the client picks a minimum level
+// with logging/setLevel and the server sends notifications/message for
+// anything at or above it. Tools log through the session stored in their
+// context with logToClient.
+
+// logLevels are the RFC 5424 severities used by MCP, least severe first.
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// defaultLogLevel applies until the client calls logging/setLevel.
+const defaultLogLevel = "warning"
+
+func logLevelRank(level string) (int, bool) {
+	for i, l := range logLevels {
+		if l == level {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+type SetLevelParams struct {
+	Level string `json:"level"`
+}
+
+type LogMessageParams struct {
+	Level  string      `json:"level"`
+	Logger string      `json:"logger,omitempty"`
+	Data   interface{} `json:"data"`
+}
+
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// notify sends a server-initiated notification to the client.
+func (s *session) notify(method string, params interface{}) {
+	if s.send != nil {
+		s.send(JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+	}
+}
+
+type requestStreamKey struct{}
+
+// contextWithRequestStream routes the notifications of the request in ctx,
+// its progress and logs, to send instead of the session's stream. The
+// HTTP transport uses it for a POST answered with its own event stream.
+func contextWithRequestStream(ctx context.Context, send func(msg interface{})) context.Context {
+	return context.WithValue(ctx, requestStreamKey{}, send)
+}
+
+// notifyRequest sends a notification about the request in ctx.
+func (s *session) notifyRequest(ctx context.Context, method string, params interface{}) {
+	if send, ok := ctx.Value(requestStreamKey{}).(func(msg interface{})); ok {
+		send(JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+		return
+	}
+	s.notify(method, params)
+}
+
+// log sends a notifications/message about the request in ctx if level
+// passes the client's filter.
+func (s *session) log(ctx context.Context, level, logger string, data interface{}) {
+	rank, ok := logLevelRank(level)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	min := s.logLevel
+	s.mu.Unlock()
+	if rank < min {
+		return
+	}
+	s.notifyRequest(ctx, "notifications/message", LogMessageParams{Level: level, Logger: logger, Data: data})
+}
+
+// setLogLevel changes the minimum level sent to the client.
+func (s *session) setLogLevel(level string) bool {
+	rank, ok := logLevelRank(level)
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logLevel = rank
+	return true
+}
+
+type sessionContextKey struct{}
+
+func contextWithSession(ctx context.Context, sess *session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, sess)
+}
+
+func sessionFromContext(ctx context.Context) *session {
+	sess, _ := ctx.Value(sessionContextKey{}).(*session)
+	return sess
+}
+
+// logToClient formats a message and logs it to the client of the session
+// in ctx, if any. logger names the emitting component, usually the tool.
+func logToClient(ctx context.Context, level, logger, format string, args ...interface{}) {
+	if sess := sessionFromContext(ctx); sess != nil {
+		sess.log(ctx, level, logger, fmt.Sprintf(format, args...))
+	}
+}
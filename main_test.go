@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServeStdioParallelCalls(t *testing.T) {
+	const calls = 200
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go func() {
+		serveStdio(inR, outW, 8)
+		outW.Close()
+	}()
+
+	// Responses are read while requests are still being written, so a
+	// full pipe never blocks the server
+	type result struct {
+		lines []string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		scanner := bufio.NewScanner(outR)
+		scanner.Buffer(make([]byte, 1024*1024), 1024*1024*10)
+		for scanner.Scan() {
+			r.lines = append(r.lines, scanner.Text())
+		}
+		r.err = scanner.Err()
+		done <- r
+	}()
+
+	fmt.Fprintln(inW, `{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	fmt.Fprintln(inW, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	var wg sync.WaitGroup
+	for i := 1; i <= calls; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			fmt.Fprintf(inW, `{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"convert","arguments":{"value":"%d","unit":"km"}}}`+"\n", id, id)
+		}(i)
+	}
+	wg.Wait()
+	inW.Close()
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("reading output: %v", r.err)
+	}
+	answered := map[int]int{}
+	for _, line := range r.lines {
+		var msg struct {
+			ID     *int            `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *RPCError       `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("output line is not one JSON message: %v\n%s", err, line)
+		}
+		if msg.ID == nil {
+			continue // a notification such as a log message
+		}
+		if msg.Error != nil {
+			t.Errorf("id %d failed: %s", *msg.ID, msg.Error.Message)
+		}
+		answered[*msg.ID]++
+	}
+	for id := 0; id <= calls; id++ {
+		if answered[id] != 1 {
+			t.Errorf("id %d answered %d times, want once", id, answered[id])
+		}
+	}
+}
+
+func init() {
+	// test_block runs until its context ends, like a tool stuck on a long
+	// input
+	RegisterTool(DefaultRegistry, Tool{
+		Name:        "test_block",
+		Description: "Blocks until cancelled (tests only)",
+		InputSchema: json.RawMessage(`{"type": "object"}`),
+	}, func(ctx context.Context, args struct{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+}
+
+// stdioClient runs serveStdio on pipes and returns functions to send a
+// line and to receive the next response that has an id.
+func stdioClient(t *testing.T, maxInFlight int) (send func(string), recv func() map[string]interface{}) {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	go func() {
+		serveStdio(inR, outW, maxInFlight)
+		outW.Close()
+	}()
+	t.Cleanup(func() { inW.Close() })
+
+	responses := make(chan map[string]interface{}, 16)
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var msg map[string]interface{}
+			if json.Unmarshal(scanner.Bytes(), &msg) == nil && msg["id"] != nil {
+				responses <- msg
+			}
+		}
+		close(responses)
+	}()
+
+	send = func(line string) { fmt.Fprintln(inW, line) }
+	recv = func() map[string]interface{} {
+		t.Helper()
+		select {
+		case msg := <-responses:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("no response within 5s")
+			return nil
+		}
+	}
+	send(`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	recv()
+	send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return send, recv
+}
+
+func TestServeStdioCancelWhileSlotsFull(t *testing.T) {
+	send, recv := stdioClient(t, 1)
+	// 1 takes the only slot and 2 waits for it; the cancellation must
+	// still be read, and end 1 without a response
+	send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"test_block","arguments":{}}}`)
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"convert","arguments":{"value":"1","unit":"km"}}}`)
+	send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`)
+	if msg := recv(); msg["id"] != float64(2) || msg["error"] != nil {
+		t.Errorf("after cancelling 1: got %v, want the result of 2", msg)
+	}
+}
+
+func TestServeStdioToolTimeout(t *testing.T) {
+	toolTimeouts["test_block"] = 50 * time.Millisecond
+	defer delete(toolTimeouts, "test_block")
+
+	send, recv := stdioClient(t, 1)
+	send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"test_block","arguments":{}}}`)
+	msg := recv()
+	result, _ := msg["result"].(map[string]interface{})
+	if msg["id"] != float64(1) || result["isError"] != true {
+		t.Fatalf("got %v, want a tool error for 1", msg)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "[timeout]") {
+		t.Errorf("error %q is not a timeout", text)
+	}
+	// The timed-out tool has returned its slot
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"convert","arguments":{"value":"1","unit":"km"}}}`)
+	if msg := recv(); msg["id"] != float64(2) || msg["error"] != nil {
+		t.Errorf("after the timeout: got %v, want the result of 2", msg)
+	}
+}
+
+func TestCallToolHoldsSlotUntilToolExits(t *testing.T) {
+	slots := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(contextWithToolSlots(context.Background(), slots))
+	done := make(chan error, 1)
+	go func() {
+		_, err := callTool(ctx, "test_block", nil)
+		done <- err
+	}()
+	for len(slots) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != errCancelled {
+		t.Fatalf("callTool: %v, want errCancelled", err)
+	}
+	// A second call waits for the slot and gives up when cancelled
+	ctx2, cancel2 := context.WithTimeout(contextWithToolSlots(context.Background(), make(chan struct{})), 10*time.Millisecond)
+	defer cancel2()
+	if _, err := callTool(ctx2, "convert", nil); err != errCancelled {
+		t.Errorf("callTool without a free slot: %v, want errCancelled", err)
+	}
+}
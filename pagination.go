@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// --- Pagination ---
+//
+// List methods return at most pageSize items and a nextCursor for the
+// rest. Cursors are opaque to clients; internally they encode the offset
+// of the next item.
+
+// pageSize is the number of items per list page, set by --page-size.
+var pageSize = 50
+
+type PaginatedParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// paginate returns the bounds of the page of n items starting at cursor,
+// and the cursor of the following page, empty on the last one.
+func paginate(n int, cursor string) (start, end int, next string, err error) {
+	if cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || !strings.HasPrefix(string(raw), "offset:") {
+			return 0, 0, "", errInvalidCursor
+		}
+		start, err = strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+		if err != nil || start < 0 || start > n {
+			return 0, 0, "", errInvalidCursor
+		}
+	}
+	end = start + pageSize
+	if pageSize <= 0 || end > n {
+		end = n
+	}
+	if end < n {
+		next = base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(end)))
+	}
+	return start, end, next, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import "strings"
+
+// --- Unit Prefixes ---
+//
+// SI prefixes combine with any prefixable symbol of dimensionalUnits, and
+// IEC binary prefixes with bits and bytes, so GW, µs, mA, PB or Kibit
+// need no row of their own.
+
+type unitPrefix struct {
+	Symbol string
+	Factor float64
+}
+
+var siPrefixes = []unitPrefix{
+	{"n", 1e-9},
+	{"µ", 1e-6}, // micro sign
+	{"μ", 1e-6}, // Greek mu
+	{"u", 1e-6},
+	{"m", 1e-3},
+	{"k", 1e3},
+	{"M", 1e6},
+	{"G", 1e9},
+	{"T", 1e12},
+	{"P", 1e15},
+}
+
+var iecPrefixes = []unitPrefix{
+	{"Ki", 1 << 10},
+	{"Mi", 1 << 20},
+	{"Gi", 1 << 30},
+	{"Ti", 1 << 40},
+	{"Pi", 1 << 50},
+}
+
+// prefixableSymbols take SI prefixes; binarySymbols also take IEC ones.
+var (
+	prefixableSymbols = []string{"m", "g", "s", "A", "K", "mol", "cd", "bit", "B", "L", "N", "J", "W", "Wh", "eV", "Pa", "Hz", "V", "Ah", "bps"}
+	binarySymbols     = []string{"bit", "B"}
+)
+
+// prefixedQuantity resolves a prefixed symbol such as GW, µs or PiB
+// against symbols.
+func prefixedQuantity(sym string, symbols map[string]quantity) (quantity, bool) {
+	for _, p := range iecPrefixes {
+		if rest, ok := strings.CutPrefix(sym, p.Symbol); ok && containsString(binarySymbols, rest) {
+			return scaledSymbol(rest, p.Factor, symbols)
+		}
+	}
+	for _, p := range siPrefixes {
+		if rest, ok := strings.CutPrefix(sym, p.Symbol); ok && containsString(prefixableSymbols, rest) {
+			return scaledSymbol(rest, p.Factor, symbols)
+		}
+	}
+	return quantity{}, false
+}
+
+func scaledSymbol(sym string, factor float64, symbols map[string]quantity) (quantity, bool) {
+	q, ok := symbols[sym]
+	q.Factor *= factor
+	return q, ok
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// --- Progress ---
+//
+// A tools/call carrying _meta.progressToken asks for notifications/progress
+// while the tool runs. Tools report through the ProgressReporter in their
+// context; without a token it discards everything, so tools report
+// unconditionally.
+
+// progressInterval is the minimum time between two progress notifications
+// for one call. The final report is always sent.
+const progressInterval = 100 * time.Millisecond
+
+// ProgressReporter receives a tool's progress. progress must increase
+// from call to call; total is 0 when unknown.
+type ProgressReporter interface {
+	Report(progress, total float64, message string)
+}
+
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+type noProgress struct{}
+
+func (noProgress) Report(progress, total float64, message string) {}
+
+// sessionProgress sends a session's progress notifications for one token.
+type sessionProgress struct {
+	sess  *session
+	ctx   context.Context // the call's, for where its notifications go
+	token interface{}
+
+	mu       sync.Mutex
+	sent     bool // a notification has gone out; last and lastSent are set
+	last     float64
+	lastSent time.Time
+}
+
+func (p *sessionProgress) Report(progress, total float64, message string) {
+	p.mu.Lock()
+	done := total > 0 && progress >= total
+	if p.sent && (progress <= p.last || (!done && time.Since(p.lastSent) < progressInterval)) {
+		p.mu.Unlock()
+		return
+	}
+	p.sent, p.last, p.lastSent = true, progress, time.Now()
+	p.mu.Unlock()
+	p.sess.notifyRequest(p.ctx, "notifications/progress", ProgressParams{
+		ProgressToken: p.token,
+		Progress:      progress,
+		Total:         total,
+		Message:       message,
+	})
+}
+
+type progressContextKey struct{}
+
+// contextWithProgress attaches a reporter for token to ctx. A token that
+// is not a string or number is ignored.
+func contextWithProgress(ctx context.Context, sess *session, token interface{}) context.Context {
+	switch token.(type) {
+	case string, float64:
+		return context.WithValue(ctx, progressContextKey{}, &sessionProgress{sess: sess, ctx: ctx, token: token})
+	}
+	return ctx
+}
+
+// progressFromContext returns the reporter for the call in ctx, or one
+// that discards reports.
+func progressFromContext(ctx context.Context) ProgressReporter {
+	if p, ok := ctx.Value(progressContextKey{}).(ProgressReporter); ok {
+		return p
+	}
+	return noProgress{}
+}
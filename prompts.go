@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- Prompts ---
+//
+// Parameterized prompt templates served through prompts/list and
+// prompts/get. Each expands into a user message that tells the model
+// which tool to call. Tool names come from getToolDefinitions(), so a
+// prompt whose tool is not registered is neither listed nor served.
+
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments"`
+}
+
+type PromptMessage struct {
+	Role    string        `json:"role"`
+	Content PromptContent `json:"content"`
+}
+
+type PromptContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// promptTemplate pairs a Prompt with the tool it drives and the function
+// that writes its message. render receives the tool's definition.
+type promptTemplate struct {
+	Prompt
+	tool   string
+	render func(tool Tool, args map[string]string) string
+}
+
+var promptTemplates = []promptTemplate{
+	{
+		Prompt: Prompt{
+			Name:        "audit_palette_contrast",
+			Description: "Audit a color palette for WCAG contrast",
+			Arguments: []PromptArgument{
+				{Name: "colors", Description: "Comma-separated colors in any syntax analyze_color accepts", Required: true},
+				{Name: "level", Description: "WCAG level to check: AA (default) or AAA"},
+			},
+		},
+		tool: "analyze_color",
+		render: func(tool Tool, args map[string]string) string {
+			level, ratio := "AA", "4.5:1"
+			if strings.EqualFold(args["level"], "AAA") {
+				level, ratio = "AAA", "7:1"
+			}
+			return fmt.Sprintf("Audit this palette for WCAG %s contrast.\n\n"+
+				"Call the `%s` tool once for each color with `color_input` set to the color:\n%s\n\n"+
+				"For each color report its hex value, its contrast against black and white, and the recommended text color. "+
+				"Then list the colors that fail %s for normal text (%s) and suggest the closest passing alternative.",
+				level, tool.Name, bulletList(args["colors"]), level, ratio)
+		},
+	},
+	{
+		Prompt: Prompt{
+			Name:        "explain_jwt",
+			Description: "Decode a JWT and explain its claims",
+			Arguments: []PromptArgument{
+				{Name: "token", Description: "The JWT to inspect", Required: true},
+			},
+		},
+		tool: "inspect_jwt",
+		render: func(tool Tool, args map[string]string) string {
+			return fmt.Sprintf("Explain this JSON Web Token.\n\n"+
+				"Call the `%s` tool with `token` set to:\n\n%s\n\n"+
+				"Describe the signing algorithm, who issued it and for whom, every registered and custom claim, "+
+				"and whether it is expired. The signature is not verified; say so rather than vouching for the token.",
+				tool.Name, args["token"])
+		},
+	},
+	{
+		Prompt: Prompt{
+			Name:        "normalize_measurements",
+			Description: "Convert a list of measurements to one unit",
+			Arguments: []PromptArgument{
+				{Name: "measurements", Description: "Comma-separated values with units, e.g. \"5 ft, 2 m, 30 in\"", Required: true},
+				{Name: "target_unit", Description: "Unit to normalize to, e.g. m"},
+			},
+		},
+		tool: "convert",
+		render: func(tool Tool, args map[string]string) string {
+			target := "the most common unit among them"
+			if args["target_unit"] != "" {
+				target = "`" + args["target_unit"] + "`"
+			}
+			return fmt.Sprintf("Normalize these measurements to %s:\n%s\n\n"+
+				"Call the `%s` tool once per measurement, with `value` set to the number and `unit` set to its unit. "+
+				"Return a table with the original measurement and the converted value, and flag any measurement whose unit was not recognized.",
+				target, bulletList(args["measurements"]), tool.Name)
+		},
+	},
+}
+
+// listPrompts returns the prompts whose tool is registered.
+func listPrompts() []Prompt {
+	prompts := []Prompt{}
+	for _, p := range promptTemplates {
+		if _, ok := lookupTool(p.tool); ok {
+			prompts = append(prompts, p.Prompt)
+		}
+	}
+	return prompts
+}
+
+// getPrompt expands prompt name with args. The error is meant for the
+// client and reports an unknown prompt or a missing required argument.
+func getPrompt(name string, args map[string]string) (description string, messages []PromptMessage, err error) {
+	for _, p := range promptTemplates {
+		if p.Name != name {
+			continue
+		}
+		tool, ok := lookupTool(p.tool)
+		if !ok {
+			break
+		}
+		for _, a := range p.Arguments {
+			if a.Required && strings.TrimSpace(args[a.Name]) == "" {
+				return "", nil, fmt.Errorf("missing required argument %q", a.Name)
+			}
+		}
+		text := p.render(tool, args)
+		return p.Description, []PromptMessage{{Role: "user", Content: PromptContent{Type: "text", Text: text}}}, nil
+	}
+	return "", nil, fmt.Errorf("unknown prompt %q", name)
+}
+
+// lookupTool finds a tool definition by name.
+func lookupTool(name string) (Tool, bool) {
+	for _, t := range getToolDefinitions() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tool{}, false
+}
+
+// bulletList turns a comma-separated argument into a markdown list.
+// Commas inside parentheses, as in rgb(1, 2, 3), do not split items.
+func bulletList(csv string) string {
+	var items []string
+	depth, start := 0, 0
+	for i, c := range csv {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, csv[start:i])
+				start = i + 1
+			}
+		}
+	}
+	items = append(items, csv[start:])
+
+	var b strings.Builder
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,68 @@
+package main
+
+import "encoding/json"
+
+// --- Protocol Negotiation ---
+
+// supportedProtocolVersions lists the MCP revisions the server speaks,
+// newest first. Revisions are dates, so they compare as strings.
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+const oldestProtocolVersion = "2024-11-05"
+
+type InitializeParams struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    ClientCapabilities `json:"capabilities"`
+	ClientInfo      Implementation     `json:"clientInfo"`
+}
+
+type ClientCapabilities struct {
+	Roots        json.RawMessage `json:"roots,omitempty"`
+	Sampling     json.RawMessage `json:"sampling,omitempty"`
+	Elicitation  json.RawMessage `json:"elicitation,omitempty"`
+	Experimental json.RawMessage `json:"experimental,omitempty"`
+}
+
+type Implementation struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version"`
+}
+
+// protocolFeatures are the optional protocol features a session may use,
+// derived from the negotiated revision and the client's capabilities.
+type protocolFeatures struct {
+	ToolAnnotations  bool // 2025-03-26: annotations on tools/list entries
+	Completions      bool // 2025-03-26: completion/complete
+	StructuredOutput bool // 2025-06-18: outputSchema and structuredContent
+	Elicitation      bool // 2025-06-18 and the client's elicitation capability: elicitation/create
+}
+
+func featuresFor(version string, caps ClientCapabilities) protocolFeatures {
+	return protocolFeatures{
+		ToolAnnotations:  version >= "2025-03-26",
+		Completions:      version >= "2025-03-26",
+		StructuredOutput: version >= "2025-06-18",
+		Elicitation:      version >= "2025-06-18" && declared(caps.Elicitation),
+	}
+}
+
+// declared reports whether a client capability is present; MCP declares
+// one with an object, even an empty one.
+func declared(capability json.RawMessage) bool {
+	return len(capability) > 0 && string(capability) != "null"
+}
+
+// negotiateProtocolVersion picks the newest revision both sides support.
+// A client requesting a revision can speak it and every older one, so
+// that is the newest supported revision not after the requested one. If
+// the client only knows revisions older than all of ours, the newest one
+// is offered and the client decides whether to disconnect.
+func negotiateProtocolVersion(requested string) string {
+	for _, v := range supportedProtocolVersions {
+		if v <= requested {
+			return v
+		}
+	}
+	return supportedProtocolVersions[0]
+}
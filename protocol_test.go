@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	for requested, want := range map[string]string{
+		"2025-06-18": "2025-06-18",
+		"2025-09-01": "2025-06-18", // newer than ours
+		"2025-03-26": "2025-03-26",
+		"2025-05-01": "2025-03-26", // between two of ours
+		"2024-11-05": "2024-11-05",
+		"2024-01-01": "2025-06-18", // older than all of ours
+	} {
+		if got := negotiateProtocolVersion(requested); got != want {
+			t.Errorf("negotiateProtocolVersion(%s) = %s, want %s", requested, got, want)
+		}
+	}
+}
+
+func TestFeaturesFor(t *testing.T) {
+	elicit := ClientCapabilities{Elicitation: json.RawMessage(`{}`)}
+	for _, tc := range []struct {
+		version string
+		caps    ClientCapabilities
+		want    protocolFeatures
+	}{
+		{"2024-11-05", elicit, protocolFeatures{}},
+		{"2025-03-26", elicit, protocolFeatures{ToolAnnotations: true, Completions: true}},
+		{"2025-06-18", ClientCapabilities{}, protocolFeatures{ToolAnnotations: true, Completions: true, StructuredOutput: true}},
+		{"2025-06-18", ClientCapabilities{Elicitation: json.RawMessage(`null`)}, protocolFeatures{ToolAnnotations: true, Completions: true, StructuredOutput: true}},
+		{"2025-06-18", elicit, protocolFeatures{ToolAnnotations: true, Completions: true, StructuredOutput: true, Elicitation: true}},
+	} {
+		if got := featuresFor(tc.version, tc.caps); got != tc.want {
+			t.Errorf("featuresFor(%s, elicitation %s) = %+v, want %+v", tc.version, tc.caps.Elicitation, got, tc.want)
+		}
+	}
+}
+
+func TestInitializeReadsClientCapabilities(t *testing.T) {
+	for _, tc := range []struct {
+		params string
+		want   bool
+	}{
+		{`{"protocolVersion":"2025-06-18","capabilities":{"elicitation":{}},"clientInfo":{"name":"test","version":"1"}}`, true},
+		{`{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}`, false},
+		{`{"protocolVersion":"2025-03-26","capabilities":{"elicitation":{}},"clientInfo":{"name":"test","version":"1"}}`, false},
+	} {
+		sess := newSession(nil)
+		resp := handleRequest(context.Background(), sess, JSONRPCRequest{JSONRPC: "2.0", ID: float64(1), Method: "initialize", Params: json.RawMessage(tc.params)})
+		if resp == nil || resp.Error != nil {
+			t.Fatalf("initialize %s: %+v", tc.params, resp)
+		}
+		if got := sess.enabled().Elicitation; got != tc.want {
+			t.Errorf("initialize %s: elicitation %v, want %v", tc.params, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// --- Tool Registry ---
+//
+// A Registry maps tool names to their MCP definition and handler. Each
+// built-in tool lives in its own tool_*.go file and adds itself to
+// DefaultRegistry from an init function, so a new tool is one new file.
+// Programs embedding the server can build their own Registry with
+// NewRegistry and RegisterTool.
+
+// ToolHandler runs a tool on its raw JSON arguments. Errors should be
+// *ToolError values so clients get a meaningful category.
+type ToolHandler func(ctx context.Context, args json.RawMessage) (interface{}, error)
+
+type registeredTool struct {
+	def     Tool
+	schema  *jsonSchema
+	handler ToolHandler
+}
+
+type Registry struct {
+	mu        sync.RWMutex
+	tools     map[string]*registeredTool
+	order     []string // registration order, used by tools/list
+	disabled  map[string]bool
+	listeners map[int]func()
+	nextID    int
+}
+
+// DefaultRegistry holds the built-in tools served by omni-tool.
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		tools:     map[string]*registeredTool{},
+		disabled:  map[string]bool{},
+		listeners: map[int]func(){},
+	}
+}
+
+// Register adds a tool with an untyped handler. It panics on a duplicate
+// name or a malformed input schema, both of which are programming errors.
+func (r *Registry) Register(def Tool, handler ToolHandler) {
+	schema, err := compileSchema(def.InputSchema)
+	if err != nil {
+		panic(fmt.Sprintf("tool %s: invalid input schema: %v", def.Name, err))
+	}
+	if len(schema.Type) != 1 || schema.Type[0] != "object" {
+		panic(fmt.Sprintf("tool %s: input schema must describe an object", def.Name))
+	}
+	if def.OutputSchema != nil {
+		out, err := compileSchema(def.OutputSchema)
+		if err != nil || len(out.Type) != 1 || out.Type[0] != "object" {
+			panic(fmt.Sprintf("tool %s: output schema must describe an object", def.Name))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.tools[def.Name]; dup {
+		panic(fmt.Sprintf("tool %s registered twice", def.Name))
+	}
+	r.tools[def.Name] = &registeredTool{def: def, schema: schema, handler: handler}
+	r.order = append(r.order, def.Name)
+}
+
+// RegisterTool adds a tool whose arguments are decoded into A, a struct
+// whose json tags match the properties of def.InputSchema.
+func RegisterTool[A any](r *Registry, def Tool, fn func(ctx context.Context, args A) (interface{}, error)) {
+	r.Register(def, func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var args A
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return nil, toolErrorf(KindInvalidInput, "Invalid arguments: %v", err)
+		}
+		return fn(ctx, args)
+	})
+}
+
+// Tools returns the definitions of every enabled tool in registration order.
+func (r *Registry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Tool, 0, len(r.order))
+	for _, name := range r.order {
+		if !r.disabled[name] {
+			out = append(out, r.tools[name].def)
+		}
+	}
+	return out
+}
+
+// SetDisabled replaces the set of disabled tools, which are hidden from
+// Tools and rejected by Call. Every name must be registered. Subscribers
+// are notified if the enabled set changed.
+func (r *Registry) SetDisabled(names []string) error {
+	disabled := map[string]bool{}
+	r.mu.Lock()
+	for _, name := range names {
+		if _, ok := r.tools[name]; !ok {
+			r.mu.Unlock()
+			return fmt.Errorf("cannot disable unknown tool %q", name)
+		}
+		disabled[name] = true
+	}
+	changed := len(disabled) != len(r.disabled)
+	for name := range disabled {
+		if !r.disabled[name] {
+			changed = true
+		}
+	}
+	r.disabled = disabled
+	var listeners []func()
+	if changed {
+		for _, fn := range r.listeners {
+			listeners = append(listeners, fn)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+	return nil
+}
+
+// Subscribe arranges for fn to run whenever the enabled tool set changes.
+// Call the returned function to stop.
+func (r *Registry) Subscribe(fn func()) (unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := r.nextID
+	r.nextID++
+	r.listeners[id] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.listeners, id)
+	}
+}
+
+// ErrUnknownTool is returned by Call for a name that was never registered
+// or is disabled.
+var ErrUnknownTool = errors.New("unknown tool")
+
+// Call validates args against the tool's input schema and runs its
+// handler. ErrUnknownTool and *ValidationError mean the call was invalid
+// and should be reported as invalid params; any other error came from the
+// tool itself. A panicking handler is recovered as a KindInternal error.
+func (r *Registry) Call(ctx context.Context, name string, args json.RawMessage) (res interface{}, err error) {
+	r.mu.RLock()
+	t, ok := r.tools[name]
+	disabled := r.disabled[name]
+	r.mu.RUnlock()
+	if !ok || disabled {
+		return nil, ErrUnknownTool
+	}
+
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage(`{}`)
+	}
+	if err := t.schema.validateJSON(args); err != nil {
+		return nil, err
+	}
+
+	defer recoverToolPanic(name, &err)
+	return t.handler(ctx, args)
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- Relative Time ---
+//
+// parseRelativeTime reads expressions such as "in 2 weeks and 3 days",
+// "next friday" or "end of quarter" against a reference time. Days, months
+// and years move along the calendar in the reference time's zone, so a day
+// across a DST change is not 24 hours and one month after Jan 31 is the
+// last day of February. relativeTimeGrammar lists the accepted forms.
+
+// relativeUnits maps unit spellings, singular, to the unit they move by.
+var relativeUnits = map[string]string{
+	"second": "second", "sec": "second",
+	"minute": "minute", "min": "minute",
+	"hour": "hour", "hr": "hour",
+	"day":  "day",
+	"week": "week", "wk": "week",
+	"fortnight": "fortnight",
+	"month":     "month", "mo": "month",
+	"quarter": "quarter", "qtr": "quarter",
+	"year": "year", "yr": "year",
+}
+
+// relativeWeekdays maps weekday names and abbreviations to weekdays.
+var relativeWeekdays = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday,
+}
+
+// relativeOrdinals maps ordinals to the occurrence they pick; -1 is last.
+var relativeOrdinals = map[string]int{
+	"first": 1, "1st": 1, "second": 2, "2nd": 2, "third": 3, "3rd": 3,
+	"fourth": 4, "4th": 4, "fifth": 5, "5th": 5, "last": -1,
+}
+
+// maxRelativeOffset bounds the number of an offset. A billion of any
+// unit, years included, stays well within the seconds time.Time counts;
+// a billion hours is not a time.Duration, so applyOffsets does not use one.
+const maxRelativeOffset = 1e9
+
+// relativeOffset is one "<number> <unit>" term.
+type relativeOffset struct {
+	N    float64
+	Unit string // a relativeUnits value or "business day"
+}
+
+// relativeParser is a recursive descent parser over the words of an
+// expression.
+type relativeParser struct {
+	words []string
+	pos   int
+	now   time.Time
+}
+
+// parseRelativeTime returns the instant input describes relative to now,
+// in now's location. ok is false if input is not a relative expression.
+func parseRelativeTime(input string, now time.Time) (t time.Time, ok bool) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	input = strings.ReplaceAll(input, ",", " and ")
+	p := &relativeParser{words: strings.Fields(input), now: now}
+	if len(p.words) == 0 {
+		return t, false
+	}
+	t, ok = p.expr()
+	return t, ok && p.pos == len(p.words)
+}
+
+func (p *relativeParser) peek(n int) string {
+	if p.pos+n < len(p.words) {
+		return p.words[p.pos+n]
+	}
+	return ""
+}
+
+// accept consumes the next words if they are words.
+func (p *relativeParser) accept(words ...string) bool {
+	for i, w := range words {
+		if p.peek(i) != w {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *relativeParser) expr() (time.Time, bool) {
+	today := startOfDay(p.now)
+	switch {
+	case p.accept("now"):
+		return p.now, true
+	case p.accept("today"):
+		return today, true
+	case p.accept("tomorrow"):
+		return today.AddDate(0, 0, 1), true
+	case p.accept("yesterday"):
+		return today.AddDate(0, 0, -1), true
+	case p.accept("in"):
+		offsets, ok := p.offsets()
+		if !ok {
+			return time.Time{}, false
+		}
+		return applyOffsets(p.now, offsets, 1)
+	case p.peek(0) == "start" || p.peek(0) == "beginning" || p.peek(0) == "end":
+		end := p.words[p.pos] == "end"
+		p.pos++
+		if !p.accept("of") {
+			return time.Time{}, false
+		}
+		start, next, ok := p.period()
+		if !ok {
+			return time.Time{}, false
+		}
+		if end {
+			return next.Add(-time.Second), true
+		}
+		return start, true
+	}
+
+	// "next friday", "last month", or "last monday of the month"
+	if dir := p.peek(0); dir == "next" || dir == "last" || dir == "this" {
+		if wd, ok := relativeWeekdays[p.peek(1)]; ok && p.peek(2) != "of" {
+			p.pos += 2
+			return nearestWeekday(today, wd, dir), true
+		}
+		if dir != "this" {
+			p.pos++
+			if u, ok := p.unit(); ok {
+				sign := 1
+				if dir == "last" {
+					sign = -1
+				}
+				return applyOffsets(p.now, []relativeOffset{{1, u}}, sign)
+			}
+			p.pos--
+		}
+	}
+	if n, ok := relativeOrdinals[p.peek(0)]; ok {
+		if wd, ok := relativeWeekdays[p.peek(1)]; ok && p.peek(2) == "of" {
+			p.pos += 3
+			start, next, ok := p.period()
+			if !ok {
+				return time.Time{}, false
+			}
+			return nthWeekday(start, next, wd, n)
+		}
+	}
+	if wd, ok := relativeWeekdays[p.peek(0)]; ok {
+		p.pos++
+		return nearestWeekday(today, wd, "this"), true
+	}
+
+	// "3 days ago", "2 business days from now", "1 week before end of month"
+	offsets, ok := p.offsets()
+	if !ok {
+		return time.Time{}, false
+	}
+	switch {
+	case p.accept("ago"):
+		return applyOffsets(p.now, offsets, -1)
+	case p.accept("from"), p.accept("after"):
+		base, ok := p.expr()
+		if !ok {
+			return time.Time{}, false
+		}
+		return applyOffsets(base, offsets, 1)
+	case p.accept("before"):
+		base, ok := p.expr()
+		if !ok {
+			return time.Time{}, false
+		}
+		return applyOffsets(base, offsets, -1)
+	}
+	return time.Time{}, false
+}
+
+// offsets parses one or more offsets joined by "and".
+func (p *relativeParser) offsets() ([]relativeOffset, bool) {
+	var offsets []relativeOffset
+	for {
+		var n float64
+		switch w := p.peek(0); {
+		case w == "a" || w == "an":
+			n = 1
+		case w != "" && (w[0] >= '0' && w[0] <= '9' || w[0] == '.'):
+			var err error
+			if n, err = strconv.ParseFloat(w, 64); err != nil || n > maxRelativeOffset {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+		p.pos++
+		u, ok := p.unit()
+		if !ok {
+			return nil, false
+		}
+		offsets = append(offsets, relativeOffset{n, u})
+		if !p.accept("and") {
+			return offsets, true
+		}
+	}
+}
+
+// unit parses a unit, which may be plural.
+func (p *relativeParser) unit() (string, bool) {
+	if w := p.peek(0); w == "business" || w == "working" {
+		if d := p.peek(1); d == "day" || d == "days" {
+			p.pos += 2
+			return "business day", true
+		}
+		return "", false
+	}
+	w := p.peek(0)
+	u, ok := relativeUnits[w]
+	if !ok {
+		u, ok = relativeUnits[strings.TrimSuffix(w, "s")]
+	}
+	if ok {
+		p.pos++
+	}
+	return u, ok
+}
+
+// period parses "[the|this|next|last] day|week|month|quarter|year" and
+// returns the start of that period and of the one after it.
+func (p *relativeParser) period() (start, next time.Time, ok bool) {
+	shift := 0
+	switch {
+	case p.accept("next"):
+		shift = 1
+	case p.accept("last"):
+		shift = -1
+	default:
+		_ = p.accept("the") || p.accept("this")
+	}
+	u, ok := p.unit()
+	switch u {
+	case "day", "week", "month", "quarter", "year":
+	default:
+		return start, next, false
+	}
+	start = startOfPeriod(p.now, u)
+	start, _ = applyOffsets(start, []relativeOffset{{float64(shift), u}}, 1)
+	next, _ = applyOffsets(start, []relativeOffset{{1, u}}, 1)
+	return start, next, true
+}
+
+// applyOffsets moves t by each offset in turn, backwards if sign is -1.
+// Calendar units and business days must be whole numbers.
+func applyOffsets(t time.Time, offsets []relativeOffset, sign int) (time.Time, bool) {
+	for _, o := range offsets {
+		n := o.N * float64(sign)
+		whole := int(n)
+		switch o.Unit {
+		case "second":
+			t = addSeconds(t, n)
+		case "minute":
+			t = addSeconds(t, n*60)
+		case "hour":
+			t = addSeconds(t, n*3600)
+		case "day", "week", "fortnight":
+			days := map[string]float64{"day": 1, "week": 7, "fortnight": 14}[o.Unit] * n
+			whole = int(days)
+			// Whole days follow the calendar, the rest is hours
+			t = t.AddDate(0, 0, whole).Add(time.Duration((days - float64(whole)) * float64(24*time.Hour)))
+		case "month", "quarter", "year":
+			if n != float64(whole) {
+				return time.Time{}, false
+			}
+			t = addMonths(t, whole*map[string]int{"month": 1, "quarter": 3, "year": 12}[o.Unit])
+		case "business day":
+			if n != float64(whole) {
+				return time.Time{}, false
+			}
+			t = addBusinessDays(t, whole)
+		}
+	}
+	return t, true
+}
+
+// addSeconds moves t by secs in absolute time, to the nanosecond. Unlike
+// Add it takes offsets past the 292 years of a time.Duration.
+func addSeconds(t time.Time, secs float64) time.Time {
+	whole := math.Trunc(secs)
+	nanos := math.Round((secs - whole) * 1e9)
+	return time.Unix(t.Unix()+int64(whole), int64(t.Nanosecond())+int64(nanos)).In(t.Location())
+}
+
+// addMonths is AddDate for months that keeps the day of the month,
+// clamped to the last day of the target month: one month after Jan 31 is
+// Feb 29 in a leap year, and a year after Feb 29 is Feb 28.
+func addMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(d, last), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// addBusinessDays moves t by n weekdays, skipping Saturdays and Sundays.
+// Public holidays are not known.
+func addBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	if n == 0 {
+		return t
+	}
+	// Step to the first few, which lands on a weekday; from there every
+	// five business days are a week
+	weeks := (n - 1) / 5
+	for n -= 5 * weeks; n > 0; {
+		t = t.AddDate(0, 0, step)
+		if wd := t.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			n--
+		}
+	}
+	return t.AddDate(0, 0, 7*weeks*step)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfPeriod returns midnight on the first day of the day, week, month,
+// quarter or year containing t. Weeks start on Monday.
+func startOfPeriod(t time.Time, unit string) time.Time {
+	y, m, d := t.Date()
+	switch unit {
+	case "week":
+		d -= (int(t.Weekday()) + 6) % 7
+	case "month":
+		d = 1
+	case "quarter":
+		m, d = m-(m-1)%3, 1
+	case "year":
+		m, d = time.January, 1
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// nearestWeekday returns midnight on the weekday wd: the first one after
+// today for "next", the last one before it for "last", and today or the
+// first one after it for "this".
+func nearestWeekday(today time.Time, wd time.Weekday, dir string) time.Time {
+	switch dir {
+	case "next":
+		days := (int(wd) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days)
+	case "last":
+		days := (int(today.Weekday()) - int(wd) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, -days)
+	}
+	return today.AddDate(0, 0, (int(wd)-int(today.Weekday())+7)%7)
+}
+
+// nthWeekday returns midnight on the nth weekday wd between start and
+// next, counting from the end if n is -1. ok is false if the period has
+// no such day, as with the fifth Monday of most months.
+func nthWeekday(start, next time.Time, wd time.Weekday, n int) (time.Time, bool) {
+	var t time.Time
+	if n < 0 {
+		last := next.AddDate(0, 0, -1)
+		t = last.AddDate(0, 0, -((int(last.Weekday()) - int(wd) + 7) % 7))
+	} else {
+		t = start.AddDate(0, 0, (int(wd)-int(start.Weekday())+7)%7+7*(n-1))
+	}
+	return t, !t.Before(start) && t.Before(next)
+}
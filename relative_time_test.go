@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRelativeTime(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC) // a Wednesday
+	for _, tc := range []struct {
+		input, want string // want is RFC 3339, or "" if input does not parse
+	}{
+		{"now", "2024-01-31T10:00:00Z"},
+		{"Today", "2024-01-31T00:00:00Z"},
+		{"tomorrow", "2024-02-01T00:00:00Z"},
+		{"in 1 month", "2024-02-29T10:00:00Z"},
+		{"next month", "2024-02-29T10:00:00Z"},
+		{"last year", "2023-01-31T10:00:00Z"},
+		{"in 2 weeks and 3 days", "2024-02-17T10:00:00Z"},
+		{"in 1 hour, 30 minutes", "2024-01-31T11:30:00Z"},
+		{"in 1.5 days", "2024-02-01T22:00:00Z"},
+		{"3 days ago", "2024-01-28T10:00:00Z"},
+		{"a year from yesterday", "2025-01-30T00:00:00Z"},
+		{"next friday", "2024-02-02T00:00:00Z"},
+		{"last monday", "2024-01-29T00:00:00Z"},
+		{"wednesday", "2024-01-31T00:00:00Z"},
+		{"end of month", "2024-01-31T23:59:59Z"},
+		{"start of quarter", "2024-01-01T00:00:00Z"},
+		{"end of next quarter", "2024-06-30T23:59:59Z"},
+		{"beginning of the week", "2024-01-29T00:00:00Z"},
+		{"last friday of the month", "2024-01-26T00:00:00Z"},
+		{"first monday of next month", "2024-02-05T00:00:00Z"},
+		{"1 week before end of month", "2024-01-24T23:59:59Z"},
+		{"2 business days from now", "2024-02-02T10:00:00Z"},
+		{"6 business days from now", "2024-02-08T10:00:00Z"},
+		{"5 working days ago", "2024-01-24T10:00:00Z"},
+		{"fifth friday of next month", ""}, // February 2024 has four
+		{"in 1.5 months", ""},
+		{"in 2.5 business days", ""},
+		{"in 3 parsecs", ""},
+		{"in 2000000000 hours", ""},
+		{"3 days", ""},
+		{"ago", ""},
+		{"", ""},
+	} {
+		got, ok := parseRelativeTime(tc.input, now)
+		switch {
+		case tc.want == "" && ok:
+			t.Errorf("parseRelativeTime(%q) = %s, want no match", tc.input, got.Format(time.RFC3339))
+		case tc.want != "" && (!ok || got.Format(time.RFC3339) != tc.want):
+			t.Errorf("parseRelativeTime(%q) = %s, %v; want %s", tc.input, got.Format(time.RFC3339), ok, tc.want)
+		}
+	}
+}
+
+func TestRelativeTimeBeyondDuration(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	got, ok := parseRelativeTime("in 1000000000 hours", now)
+	if want := time.Unix(now.Unix()+3600e9, 0).UTC(); !ok || !got.Equal(want) {
+		t.Errorf("in 1000000000 hours = %s, %v; want %s", got, ok, want)
+	}
+}
+
+func TestRelativeTimeAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	now := time.Date(2024, 3, 9, 12, 0, 0, 0, ny)
+	for input, want := range map[string]string{
+		"in 1 day":    "2024-03-10T12:00:00-04:00", // a calendar day is 23 hours here
+		"in 24 hours": "2024-03-10T13:00:00-04:00",
+		"tomorrow":    "2024-03-10T00:00:00-05:00",
+	} {
+		if got, ok := parseRelativeTime(input, now); !ok || got.Format(time.RFC3339) != want {
+			t.Errorf("%s = %s, %v; want %s", input, got.Format(time.RFC3339), ok, want)
+		}
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	for _, tc := range []struct {
+		from   string
+		months int
+		want   string
+	}{
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2023-01-31", 1, "2023-02-28"},
+		{"2024-02-29", 12, "2025-02-28"},
+		{"2024-03-31", -1, "2024-02-29"},
+		{"2024-10-31", 1, "2024-11-30"},
+		{"2024-12-31", 2, "2025-02-28"},
+		{"2024-01-15", -13, "2022-12-15"},
+	} {
+		from, _ := time.Parse("2006-01-02", tc.from)
+		if got := addMonths(from, tc.months).Format("2006-01-02"); got != tc.want {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tc.from, tc.months, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --- Resources ---
+//
+// Read-only reference data exposed through resources/list, resources/read
+// and resources/templates/list, so clients can discover what the tools
+// accept without calling them. Every resource is JSON under omni://.
+
+const resourceMimeType = "application/json"
+
+// codeResourceNotFound is the JSON-RPC error for an unknown resource URI.
+const codeResourceNotFound = -32002
+
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+var resourceTemplates = []ResourceTemplate{
+	{URITemplate: "omni://units/{category}", Name: "units-by-category", Description: "Units of one category with their aliases and conversion factors", MimeType: resourceMimeType},
+	{URITemplate: "omni://colors/palettes/{name}", Name: "palette", Description: "Hex colors of a named palette", MimeType: resourceMimeType},
+}
+
+// listResources returns the fixed resources followed by every expansion
+// of the templates.
+func listResources() []Resource {
+	res := []Resource{
+		{URI: "omni://units", Name: "units", Description: "Unit catalog for convert and compare, by category", MimeType: resourceMimeType},
+		{URI: "omni://colors/syntaxes", Name: "color-syntaxes", Description: "Color syntaxes analyze_color parses", MimeType: resourceMimeType},
+		{URI: "omni://colors/palettes", Name: "palettes", Description: "Names of the built-in color palettes", MimeType: resourceMimeType},
+		{URI: "omni://time/relative-grammar", Name: "relative-time-grammar", Description: "Relative time expressions convert understands", MimeType: resourceMimeType},
+	}
+	for _, c := range unitCategories {
+		res = append(res, Resource{
+			URI:         "omni://units/" + c.Name,
+			Name:        c.Name + "-units",
+			Description: fmt.Sprintf("Units of the %s category", c.Name),
+			MimeType:    resourceMimeType,
+		})
+	}
+	for _, name := range paletteNames() {
+		res = append(res, Resource{
+			URI:         "omni://colors/palettes/" + name,
+			Name:        name + "-palette",
+			Description: fmt.Sprintf("Hex colors of the %s palette", name),
+			MimeType:    resourceMimeType,
+		})
+	}
+	return res
+}
+
+// readResource returns the contents of uri, or false if no such resource
+// exists.
+func readResource(uri string) ([]ResourceContents, bool) {
+	data, ok := resourceData(uri)
+	if !ok {
+		return nil, false
+	}
+	text, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, false
+	}
+	return []ResourceContents{{URI: uri, MimeType: resourceMimeType, Text: string(text)}}, true
+}
+
+func resourceData(uri string) (interface{}, bool) {
+	switch uri {
+	case "omni://units":
+		catalog := map[string][]string{}
+		for _, c := range unitCategories {
+			catalog[c.Name] = categorySpellings(c.Name)
+		}
+		return catalog, true
+	case "omni://colors/syntaxes":
+		return colorSyntaxes, true
+	case "omni://colors/palettes":
+		return paletteNames(), true
+	case "omni://time/relative-grammar":
+		return relativeTimeGrammar, true
+	}
+	if cat := strings.TrimPrefix(uri, "omni://units/"); cat != uri {
+		c, ok := findCategory(cat)
+		if !ok {
+			return nil, false
+		}
+		if c.Name == "color" {
+			return map[string]interface{}{"category": c.Name, "keywords": colorKeywordUnits}, true
+		}
+		return map[string]interface{}{"category": c.Name, "base": c.Base, "units": categoryUnits(c.Name)}, true
+	}
+	if name := strings.TrimPrefix(uri, "omni://colors/palettes/"); name != uri {
+		if colors, ok := palette(name); ok {
+			return map[string]interface{}{"name": name, "colors": colors}, true
+		}
+	}
+	return nil, false
+}
+
+// paletteNames lists colorPalettes plus the "named" palette of color
+// keywords, sorted.
+func paletteNames() []string {
+	names := []string{"named"}
+	for name := range colorPalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func palette(name string) (interface{}, bool) {
+	if name != "named" {
+		colors, ok := colorPalettes[name]
+		return colors, ok
+	}
+	named := map[string]string{}
+	for kw, c := range namedColors {
+		if c[3] == 255 {
+			named[kw] = fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+		} else {
+			named[kw] = fmt.Sprintf("#%02x%02x%02x%02x", c[0], c[1], c[2], c[3])
+		}
+	}
+	return named, true
+}
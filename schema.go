@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- JSON Schema Validation ---
+//
+// jsonSchema implements the subset of JSON Schema that tool input schemas
+// use: type (single or list), properties, required, additionalProperties,
+// items, enum, numeric bounds, string length and pattern, and array size.
+
+type jsonSchema struct {
+	Type                 schemaType             `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaType accepts both "type": "string" and "type": ["string", "null"].
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(b []byte) error {
+	var one string
+	if json.Unmarshal(b, &one) == nil {
+		*t = schemaType{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("type must be a string or list of strings")
+	}
+	*t = many
+	return nil
+}
+
+// additionalProperties accepts both "additionalProperties": false and a
+// schema that every property not listed in properties must match.
+type additionalProperties struct {
+	allowed bool
+	schema  *jsonSchema
+}
+
+func (a *additionalProperties) UnmarshalJSON(b []byte) error {
+	if json.Unmarshal(b, &a.allowed) == nil {
+		return nil
+	}
+	a.allowed = true
+	return json.Unmarshal(b, &a.schema)
+}
+
+// ValidationError reports the first argument that does not match a schema.
+type ValidationError struct {
+	Path    string // e.g. "numbers[3]"
+	Message string // e.g. "expected number"
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+func compileSchema(raw json.RawMessage) (*jsonSchema, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *jsonSchema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.schema != nil {
+		if err := s.AdditionalProperties.schema.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// validateJSON decodes raw and validates it against s. Numbers are decoded
+// as json.Number so integers can be told apart from floats.
+func (s *jsonSchema) validateJSON(raw json.RawMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return &ValidationError{Path: "arguments", Message: "malformed JSON"}
+	}
+	return s.validate("arguments", v)
+}
+
+func (s *jsonSchema) validate(path string, v interface{}) error {
+	if len(s.Type) > 0 && !s.matchesType(v) {
+		return &ValidationError{Path: path, Message: "expected " + strings.Join(s.Type, " or ") + ", got " + jsonTypeName(v)}
+	}
+
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		opts := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			b, _ := json.Marshal(e)
+			opts[i] = string(b)
+		}
+		return &ValidationError{Path: path, Message: "must be one of " + strings.Join(opts, ", ")}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, req := range s.Required {
+			if _, ok := val[req]; !ok {
+				return &ValidationError{Path: joinPath(path, req), Message: "required property missing"}
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop, ok := s.Properties[k]
+			if !ok && s.AdditionalProperties != nil {
+				if !s.AdditionalProperties.allowed {
+					return &ValidationError{Path: joinPath(path, k), Message: "unknown property"}
+				}
+				prop = s.AdditionalProperties.schema
+			}
+			if prop == nil {
+				continue
+			}
+			if err := prop.validate(joinPath(path, k), val[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d items", *s.MinItems)}
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d items", *s.MaxItems)}
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d characters", *s.MinLength)}
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d characters", *s.MaxLength)}
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("does not match pattern %s", s.Pattern)}
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be >= %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("must be <= %v", *s.Maximum)}
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) matchesType(v interface{}) bool {
+	for _, t := range s.Type {
+		switch t {
+		case "object":
+			if _, ok := v.(map[string]interface{}); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]interface{}); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "null":
+			if v == nil {
+				return true
+			}
+		case "number":
+			if _, ok := v.(json.Number); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := v.(json.Number); ok {
+				f, err := n.Float64()
+				if err == nil && f == math.Trunc(f) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	got, _ := json.Marshal(v)
+	for _, e := range enum {
+		want, _ := json.Marshal(e)
+		if bytes.Equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPath(path, key string) string {
+	if path == "arguments" {
+		return key
+	}
+	return path + "." + key
+}
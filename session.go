@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Session State ---
+
+// session holds the state shared by every request from one client: the
+// stdio transport has a single session for the life of the process, the
+// HTTP transport one per Mcp-Session-Id.
+type session struct {
+	mu          sync.Mutex
+	inflight    map[string]context.CancelFunc // keyed by requestKey
+	initialized bool
+	protocol    string
+	features    protocolFeatures
+	logLevel    int // rank in logLevels
+
+	// send delivers a server-initiated message to the client; the stdio
+	// transport writes it to stdout, the HTTP transport to the event stream.
+	send func(msg interface{})
+}
+
+func newSession(send func(msg interface{})) *session {
+	rank, _ := logLevelRank(defaultLogLevel)
+	return &session{
+		inflight: map[string]context.CancelFunc{},
+		protocol: oldestProtocolVersion,
+		features: featuresFor(oldestProtocolVersion, ClientCapabilities{}),
+		logLevel: rank,
+		send:     send,
+	}
+}
+
+// initialize records the outcome of the handshake. It fails if the
+// session was already initialized.
+func (s *session) initialize(version string, caps ClientCapabilities) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.initialized {
+		return false
+	}
+	s.initialized = true
+	s.protocol = version
+	s.features = featuresFor(version, caps)
+	return true
+}
+
+func (s *session) isInitialized() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.initialized
+}
+
+// toolsChanged tells an initialized client to refetch tools/list.
+func (s *session) toolsChanged() {
+	if s.isInitialized() {
+		s.notify("notifications/tools/list_changed", nil)
+	}
+}
+
+// enabled returns the features the negotiated revision allows.
+func (s *session) enabled() protocolFeatures {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.features
+}
+
+// protocolVersion returns the negotiated revision.
+func (s *session) protocolVersion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.protocol
+}
+
+// begin registers request id as in flight and returns the context it runs
+// under. Transports call it before handing the request to another
+// goroutine, so a notifications/cancelled read right after the request
+// always finds it.
+func (s *session) begin(parent context.Context, id interface{}) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight[requestKey(id)] = cancel
+	return ctx
+}
+
+// end releases the context of request id.
+func (s *session) end(id interface{}) {
+	s.mu.Lock()
+	cancel, ok := s.inflight[requestKey(id)]
+	delete(s.inflight, requestKey(id))
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// cancel aborts the in-flight request id, reporting whether one was found.
+func (s *session) cancel(id interface{}) bool {
+	s.mu.Lock()
+	cancel, ok := s.inflight[requestKey(id)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// requestKey normalizes a JSON-RPC id so that 1 and "1" stay distinct.
+func requestKey(id interface{}) string {
+	b, _ := json.Marshal(id)
+	return string(b)
+}
+
+// --- Tool Deadlines ---
+
+// defaultToolTimeout bounds every tools/call; toolTimeouts overrides it
+// per tool name. Both are set from flags in main.
+var (
+	defaultToolTimeout = 30 * time.Second
+	toolTimeouts       = map[string]time.Duration{}
+)
+
+func toolTimeout(name string) time.Duration {
+	if d, ok := toolTimeouts[name]; ok {
+		return d
+	}
+	return defaultToolTimeout
+}
+
+// parseToolTimeouts parses the --tool-timeouts flag, a comma separated list
+// of name=duration pairs such as "calculate_statistics=5s,compare=500ms".
+func parseToolTimeouts(spec string) (map[string]time.Duration, error) {
+	out := map[string]time.Duration{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, dur, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tool timeout %q, want name=duration", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(dur))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration for tool %s: %q", name, dur)
+		}
+		out[strings.TrimSpace(name)] = d
+	}
+	return out, nil
+}
+
+// errCancelled is returned by callTool when the client cancelled the request.
+var errCancelled = errors.New("request cancelled")
+
+type toolSlotsKey struct{}
+
+// contextWithToolSlots makes callTool take one of slots for as long as
+// the tool runs, bounding the calls a transport runs at once.
+func contextWithToolSlots(ctx context.Context, slots chan struct{}) context.Context {
+	return context.WithValue(ctx, toolSlotsKey{}, slots)
+}
+
+// callTool runs a tool under its deadline. The tool runs on its own
+// goroutine so that the call returns as soon as ctx is done, even if the
+// tool has not reached its next cancellation check yet. A slot from
+// contextWithToolSlots is taken before the deadline starts and released
+// only when that goroutine exits, so abandoned tools still count. Besides
+// the errors of Registry.Call it returns errCancelled, or a KindTimeout
+// ToolError once the deadline passes.
+func callTool(ctx context.Context, name string, args json.RawMessage) (interface{}, error) {
+	release := func() {}
+	if slots, ok := ctx.Value(toolSlotsKey{}).(chan struct{}); ok {
+		select {
+		case slots <- struct{}{}:
+			release = func() { <-slots }
+		case <-ctx.Done():
+			return nil, errCancelled
+		}
+	}
+
+	timeout := toolTimeout(name)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		val interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer release()
+		val, err := DefaultRegistry.Call(ctx, name, args)
+		done <- result{val, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.val, r.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, toolErrorf(KindTimeout, "Tool %s timed out after %v", name, timeout)
+		}
+		return nil, errCancelled
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // zone data for hosts and images without /usr/share/zoneinfo
+)
+
+// --- Time Zones ---
+//
+// A zone is given as an IANA name (America/New_York), a common
+// abbreviation (PST, CET) or a UTC offset (+05:30, UTC-8). Abbreviations
+// stand for the zone that uses them, so PST in July renders as PDT.
+
+// zoneAbbreviations maps abbreviations to the IANA zone that uses them.
+// Ambiguous ones take their most common meaning: IST is India, CST is US
+// Central.
+var zoneAbbreviations = map[string]string{
+	"PST": "America/Los_Angeles", "PDT": "America/Los_Angeles",
+	"MST": "America/Denver", "MDT": "America/Denver",
+	"CST": "America/Chicago", "CDT": "America/Chicago",
+	"EST": "America/New_York", "EDT": "America/New_York",
+	"AKST": "America/Anchorage", "AKDT": "America/Anchorage",
+	"HST": "Pacific/Honolulu",
+	"AST": "America/Halifax", "ADT": "America/Halifax",
+	"NST": "America/St_Johns", "NDT": "America/St_Johns",
+	"BRT": "America/Sao_Paulo",
+	"BST": "Europe/London",
+	"WET": "Europe/Lisbon", "WEST": "Europe/Lisbon",
+	"CET": "Europe/Paris", "CEST": "Europe/Paris",
+	"EET": "Europe/Athens", "EEST": "Europe/Athens",
+	"MSK":  "Europe/Moscow",
+	"IST":  "Asia/Kolkata",
+	"PKT":  "Asia/Karachi",
+	"ICT":  "Asia/Bangkok",
+	"WIB":  "Asia/Jakarta",
+	"SGT":  "Asia/Singapore",
+	"HKT":  "Asia/Hong_Kong",
+	"JST":  "Asia/Tokyo",
+	"KST":  "Asia/Seoul",
+	"AWST": "Australia/Perth",
+	"ACST": "Australia/Adelaide", "ACDT": "Australia/Adelaide",
+	"AEST": "Australia/Sydney", "AEDT": "Australia/Sydney",
+	"NZST": "Pacific/Auckland", "NZDT": "Pacific/Auckland",
+	"SAST": "Africa/Johannesburg",
+	"WAT":  "Africa/Lagos",
+	"EAT":  "Africa/Nairobi",
+}
+
+// zoneOffsetPattern matches UTC offsets such as +05:30, -0800, +5 or
+// UTC-3.
+var zoneOffsetPattern = regexp.MustCompile(`^(?:UTC|GMT)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// localServerZone is the world_clock entry for the server's own zone.
+const localServerZone = "Local_Server"
+
+// resolveZone returns the location spec names; ok is false if spec is not
+// a zone. An empty spec is UTC.
+func resolveZone(spec string) (loc *time.Location, ok bool) {
+	spec = strings.TrimSpace(spec)
+	upper := strings.ToUpper(spec)
+	switch upper {
+	case "", "UTC", "Z", "GMT":
+		return time.UTC, true
+	case strings.ToUpper(localServerZone):
+		return time.Local, true
+	}
+	if m := zoneOffsetPattern.FindStringSubmatch(upper); m != nil {
+		h, _ := strconv.Atoi(m[2])
+		mins, _ := strconv.Atoi("0" + m[3])
+		if h > 14 || mins > 59 {
+			return nil, false
+		}
+		secs := h*3600 + mins*60
+		if m[1] == "-" {
+			secs = -secs
+		}
+		return time.FixedZone(formatZoneOffset(secs), secs), true
+	}
+	if name, found := zoneAbbreviations[upper]; found {
+		spec = name
+	}
+	loc, err := time.LoadLocation(spec)
+	return loc, err == nil && spec != "Local"
+}
+
+// isTimeZone reports whether s names a zone rather than a unit or
+// currency. Short tz database links like GB or NZ lose to units.
+func isTimeZone(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, ok := resolveZone(s)
+		return ok
+	}
+	if s == "" || isCurrency(s) || isDimensionalUnit(s) {
+		return false
+	}
+	if _, ok := lookupUnit(s); ok {
+		return false
+	}
+	_, ok := resolveZone(s)
+	return ok
+}
+
+// formatZoneOffset renders an offset in seconds as +05:30.
+func formatZoneOffset(secs int) string {
+	sign := '+'
+	if secs < 0 {
+		sign, secs = '-', -secs
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, secs/3600, secs/60%60)
+}
+
+// TimeZoneInfo describes the zone a time was rendered in.
+type TimeZoneInfo struct {
+	Requested    string `json:"requested,omitempty"`
+	Name         string `json:"name"`
+	Abbreviation string `json:"abbreviation"`
+	Offset       string `json:"offset"`
+	DST          bool   `json:"dst"`
+}
+
+func zoneInfo(requested string, t time.Time) *TimeZoneInfo {
+	abbr, off := t.Zone()
+	return &TimeZoneInfo{Requested: requested, Name: t.Location().String(), Abbreviation: abbr, Offset: formatZoneOffset(off), DST: t.IsDST()}
+}
+
+// DSTTransition flags a wall-clock time that a zone's daylight saving
+// transition makes ambiguous (it occurs twice) or skipped (it never
+// occurs).
+type DSTTransition struct {
+	Status     string   `json:"status"` // "ambiguous" or "skipped"
+	Zone       string   `json:"zone"`
+	WallClock  string   `json:"wall_clock"`
+	Candidates []string `json:"candidates"` // the instants the wall clock could mean
+	Chosen     string   `json:"chosen"`
+	Note       string   `json:"note"`
+}
+
+// checkWallClock looks for a DST transition at the wall-clock reading of
+// wall, whose location is ignored, in loc. It returns the instant to use
+// and a flag unless the reading is unique: an ambiguous reading takes its
+// first occurrence, a skipped one moves forward by the length of the gap.
+func checkWallClock(wall time.Time, loc *time.Location) (time.Time, *DSTTransition) {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	asUTC := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), time.UTC)
+
+	// A transition changes the offset by hours at most, so the offsets half
+	// a day either side are the only ones the reading can have
+	var candidates []time.Time
+	var offsets []int
+	for _, probe := range []time.Time{t.Add(-12 * time.Hour), t.Add(12 * time.Hour)} {
+		_, off := probe.Zone()
+		if containsInt(offsets, off) {
+			continue
+		}
+		offsets = append(offsets, off)
+		c := asUTC.Add(-time.Duration(off) * time.Second).In(loc)
+		if _, cOff := c.Zone(); cOff == off {
+			candidates = append(candidates, c)
+		}
+	}
+
+	wallText := asUTC.Format("2006-01-02T15:04:05")
+	switch len(candidates) {
+	case 1:
+		return candidates[0], nil
+	case 0:
+		// offsets[0] is the offset before the gap
+		var times []time.Time
+		for _, off := range offsets {
+			times = append(times, asUTC.Add(-time.Duration(off)*time.Second).In(loc))
+		}
+		flag := &DSTTransition{Status: "skipped", Zone: loc.String(), WallClock: wallText, Chosen: times[0].Format(time.RFC3339)}
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+		for _, c := range times {
+			flag.Candidates = append(flag.Candidates, c.Format(time.RFC3339))
+		}
+		flag.Note = fmt.Sprintf("%s does not exist in %s; it falls in the gap when clocks spring forward", wallText, loc)
+		return asUTC.Add(-time.Duration(offsets[0]) * time.Second).In(loc), flag
+	default:
+		if candidates[1].Before(candidates[0]) {
+			candidates[0], candidates[1] = candidates[1], candidates[0]
+		}
+		return candidates[0], &DSTTransition{
+			Status:     "ambiguous",
+			Zone:       loc.String(),
+			WallClock:  wallText,
+			Candidates: []string{candidates[0].Format(time.RFC3339), candidates[1].Format(time.RFC3339)},
+			Chosen:     candidates[0].Format(time.RFC3339),
+			Note:       fmt.Sprintf("%s occurs twice in %s as clocks fall back; the first occurrence is used", wallText, loc),
+		}
+	}
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	worldClockMu    sync.RWMutex
+	worldClockZones = []string{"UTC", localServerZone}
+)
+
+// checkZones rejects zones that do not resolve.
+func checkZones(zones []string) error {
+	for _, z := range zones {
+		if _, ok := resolveZone(z); !ok || strings.TrimSpace(z) == "" {
+			return fmt.Errorf("unknown time zone %q", z)
+		}
+	}
+	return nil
+}
+
+// setWorldClock replaces the world_clock zones, which checkZones has
+// accepted. nil restores the default of UTC and the server's zone.
+func setWorldClock(zones []string) {
+	if zones == nil {
+		zones = []string{"UTC", localServerZone}
+	}
+	worldClockMu.Lock()
+	worldClockZones = zones
+	worldClockMu.Unlock()
+}
+
+// worldClock renders t in every world_clock zone, keyed as configured.
+func worldClock(t time.Time) map[string]string {
+	worldClockMu.RLock()
+	defer worldClockMu.RUnlock()
+	clock := map[string]string{}
+	for _, z := range worldClockZones {
+		loc, _ := resolveZone(z)
+		clock[z] = t.In(loc).Format(time.RFC3339)
+	}
+	return clock
+}
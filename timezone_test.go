@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveZone(t *testing.T) {
+	for _, tc := range []struct {
+		spec, name string
+		offset     int // seconds east of UTC on 2024-01-15
+	}{
+		{"", "UTC", 0},
+		{"Z", "UTC", 0},
+		{"Asia/Tokyo", "Asia/Tokyo", 9 * 3600},
+		{"PST", "America/Los_Angeles", -8 * 3600},
+		{"ist", "Asia/Kolkata", 5*3600 + 1800},
+		{"+05:30", "+05:30", 5*3600 + 1800},
+		{"UTC-8", "-08:00", -8 * 3600},
+		{"-0330", "-03:30", -(3*3600 + 1800)},
+	} {
+		loc, ok := resolveZone(tc.spec)
+		if !ok {
+			t.Errorf("resolveZone(%q) failed", tc.spec)
+			continue
+		}
+		_, off := time.Date(2024, 1, 15, 12, 0, 0, 0, loc).Zone()
+		if loc.String() != tc.name || off != tc.offset {
+			t.Errorf("resolveZone(%q) = %s %+d, want %s %+d", tc.spec, loc, off, tc.name, tc.offset)
+		}
+	}
+	for _, spec := range []string{"Mars/Olympus", "+15", "+05:75", "Local", "km"} {
+		if _, ok := resolveZone(spec); ok {
+			t.Errorf("resolveZone(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestIsTimeZone(t *testing.T) {
+	for s, want := range map[string]bool{
+		"Asia/Tokyo":       true,
+		"America/New_York": true,
+		"PST":              true,
+		"UTC+2":            true,
+		"":                 false,
+		"km":               false,
+		"EUR":              false,
+		"m/s":              false,
+		"iso":              false,
+	} {
+		if got := isTimeZone(s); got != want {
+			t.Errorf("isTimeZone(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestCheckWallClock(t *testing.T) {
+	for _, tc := range []struct {
+		zone, wall string
+		status     string // "" for a unique reading
+		chosen     string
+	}{
+		{"America/New_York", "2024-07-01T12:00:00", "", "2024-07-01T12:00:00-04:00"},
+		{"America/New_York", "2024-03-10T02:30:00", "skipped", "2024-03-10T03:30:00-04:00"},
+		{"America/New_York", "2024-11-03T01:30:00", "ambiguous", "2024-11-03T01:30:00-04:00"},
+		{"Europe/London", "2024-03-31T01:30:00", "skipped", "2024-03-31T02:30:00+01:00"},
+		{"Europe/London", "2024-10-27T01:30:00", "ambiguous", "2024-10-27T01:30:00+01:00"},
+		{"Australia/Lord_Howe", "2024-04-07T01:45:00", "ambiguous", "2024-04-07T01:45:00+11:00"}, // a half-hour shift
+		{"Asia/Tokyo", "2024-03-10T02:30:00", "", "2024-03-10T02:30:00+09:00"},
+	} {
+		loc, ok := resolveZone(tc.zone)
+		if !ok {
+			t.Fatalf("no zone %s", tc.zone)
+		}
+		wall, _ := time.Parse("2006-01-02T15:04:05", tc.wall)
+		got, flag := checkWallClock(wall, loc)
+		status := ""
+		if flag != nil {
+			status = flag.Status
+		}
+		if status != tc.status || got.Format(time.RFC3339) != tc.chosen {
+			t.Errorf("%s %s: %s %q, want %s %q", tc.wall, tc.zone, got.Format(time.RFC3339), status, tc.chosen, tc.status)
+		}
+		if flag != nil && (flag.Chosen != tc.chosen || len(flag.Candidates) != 2) {
+			t.Errorf("%s %s: flag %+v", tc.wall, tc.zone, flag)
+		}
+	}
+}
+
+func TestConvertTimeZones(t *testing.T) {
+	for _, tc := range []struct {
+		input, zone, render string
+		iso, abbr, dst      string
+	}{
+		{"2024-01-02T03:04:05Z", "", "Asia/Tokyo", "2024-01-02T12:04:05+09:00", "JST", ""},
+		{"2024-01-02T03:04:05Z", "iso", "Asia/Tokyo", "2024-01-02T12:04:05+09:00", "JST", ""},
+		{"2024-07-01 12:00", "PST", "", "2024-07-01T12:00:00-07:00", "PDT", ""},
+		{"2024-07-01 12:00", "Europe/Paris", "UTC", "2024-07-01T10:00:00Z", "UTC", ""},
+		{"2024-03-10 02:30", "America/New_York", "", "2024-03-10T03:30:00-04:00", "EDT", "skipped"},
+		{"2024-11-03 01:30", "America/New_York", "", "2024-11-03T01:30:00-04:00", "EDT", "ambiguous"},
+		{"1730611800", "", "America/New_York", "2024-11-03T01:30:00-04:00", "EDT", "ambiguous"}, // rendered into the repeated hour
+	} {
+		res, err := toolConvertTime(tc.input, tc.zone, tc.render, "")
+		if err != nil {
+			t.Errorf("%q in %q: %v", tc.input, tc.zone, err)
+			continue
+		}
+		tcv := res.(*TimeConversion)
+		status := ""
+		if tcv.DST != nil {
+			status = tcv.DST.Status
+		}
+		if tcv.Formats["iso"] != tc.iso || tcv.Zone.Abbreviation != tc.abbr || status != tc.dst {
+			t.Errorf("%q in %q to %q: %s %s dst %q, want %s %s dst %q", tc.input, tc.zone, tc.render, tcv.Formats["iso"], tcv.Zone.Abbreviation, status, tc.iso, tc.abbr, tc.dst)
+		}
+	}
+	if _, err := toolConvertTime("2024-01-02", "", "Mars/Olympus", ""); err == nil {
+		t.Error("unknown render zone: no error")
+	}
+}
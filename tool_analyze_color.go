@@ -0,0 +1,555 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type analyzeColorArgs struct {
+	ColorInput string `json:"color_input"`
+}
+
+func init() {
+	RegisterTool(DefaultRegistry, Tool{
+		Name:        "analyze_color",
+		Description: "Takes a color (Hex, RGB) and returns conversions plus accessibility analysis.",
+		Annotations: &ToolAnnotations{Title: "Analyze Color", ReadOnlyHint: true, IdempotentHint: true},
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"color_input": {"type": "string"}
+			},
+			"required": ["color_input"]
+		}`),
+		OutputSchema: json.RawMessage(colorAnalysisSchema),
+	}, func(ctx context.Context, args analyzeColorArgs) (interface{}, error) {
+		return toolAnalyzeColor(ctx, args.ColorInput)
+	})
+}
+
+// ColorAnalysis lists a color in every supported syntax together with
+// its WCAG contrast against black and white.
+type ColorAnalysis struct {
+	OriginalInput string                 `json:"original_input"`
+	HasAlpha      bool                   `json:"has_alpha"`
+	Formats       map[string]interface{} `json:"formats"`
+	Accessibility ColorAccessibility     `json:"accessibility"`
+}
+
+type ColorAccessibility struct {
+	Luminance            float64 `json:"luminance"`
+	ContrastWhite        float64 `json:"contrast_white"`
+	ContrastBlack        float64 `json:"contrast_black"`
+	WCAGAACompliant      bool    `json:"wcag_aa_compliant"`
+	RecommendedTextColor string  `json:"recommended_text_color"`
+}
+
+const colorAnalysisSchema = `{
+	"type": "object",
+	"properties": {
+		"original_input": {"type": "string"},
+		"has_alpha": {"type": "boolean"},
+		"formats": {
+			"type": "object",
+			"description": "hex, rgb, hsl, hsv, hwb, cmyk, lab, lch, oklab, oklch (object and *_css string forms), ansi256, plus alpha variants",
+			"properties": {
+				"hex": {"type": "string"},
+				"rgb_css": {"type": "string"},
+				"ansi256": {"type": "integer"}
+			}
+		},
+		"accessibility": {
+			"type": "object",
+			"properties": {
+				"luminance": {"type": "number"},
+				"contrast_white": {"type": "number"},
+				"contrast_black": {"type": "number"},
+				"wcag_aa_compliant": {"type": "boolean"},
+				"recommended_text_color": {"enum": ["black", "white"]}
+			},
+			"required": ["luminance", "contrast_white", "contrast_black", "wcag_aa_compliant", "recommended_text_color"]
+		}
+	},
+	"required": ["original_input", "has_alpha", "formats", "accessibility"]
+}`
+
+// colorSyntaxes describes each input syntax toolAnalyzeColor accepts.
+// It backs the omni://colors/syntaxes resource.
+var colorSyntaxes = []struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Example string `json:"example"`
+}{
+	{"hex", "#RGB, #RGBA, #RRGGBB, #RRGGBBAA (leading # optional)", "#1e90ff"},
+	{"rgb", "rgb(r, g, b) with 0-255 channels", "rgb(30, 144, 255)"},
+	{"rgba", "rgba(r, g, b, a) with alpha 0-1 or 0-255", "rgba(30, 144, 255, 0.5)"},
+	{"hsl", "hsl(h, s%, l%) or hsla(h, s%, l%, a)", "hsl(210, 100%, 56%)"},
+	{"hwb", "hwb(h w% b%) or hwb(h w% b% / a)", "hwb(210 12% 0%)"},
+	{"lab", "lab(l a b) or lab(l a b / a)", "lab(58 5 -58)"},
+	{"lch", "lch(l c h) or lch(l c h / a)", "lch(58 58 275)"},
+	{"oklab", "oklab(l a b) or oklab(l a b / a)", "oklab(0.65 -0.05 -0.17)"},
+	{"oklch", "oklch(l c h) or oklch(l c h / a)", "oklch(0.65 0.18 255)"},
+	{"named", "CSS color keyword, see omni://colors/palettes/named", "blue"},
+}
+
+// namedColors maps the CSS Color Module Level 4 keywords to RGBA.
+var namedColors = map[string][4]int{
+	"aliceblue":            {240, 248, 255, 255},
+	"antiquewhite":         {250, 235, 215, 255},
+	"aqua":                 {0, 255, 255, 255},
+	"aquamarine":           {127, 255, 212, 255},
+	"azure":                {240, 255, 255, 255},
+	"beige":                {245, 245, 220, 255},
+	"bisque":               {255, 228, 196, 255},
+	"black":                {0, 0, 0, 255},
+	"blanchedalmond":       {255, 235, 205, 255},
+	"blue":                 {0, 0, 255, 255},
+	"blueviolet":           {138, 43, 226, 255},
+	"brown":                {165, 42, 42, 255},
+	"burlywood":            {222, 184, 135, 255},
+	"cadetblue":            {95, 158, 160, 255},
+	"chartreuse":           {127, 255, 0, 255},
+	"chocolate":            {210, 105, 30, 255},
+	"coral":                {255, 127, 80, 255},
+	"cornflowerblue":       {100, 149, 237, 255},
+	"cornsilk":             {255, 248, 220, 255},
+	"crimson":              {220, 20, 60, 255},
+	"cyan":                 {0, 255, 255, 255},
+	"darkblue":             {0, 0, 139, 255},
+	"darkcyan":             {0, 139, 139, 255},
+	"darkgoldenrod":        {184, 134, 11, 255},
+	"darkgray":             {169, 169, 169, 255},
+	"darkgreen":            {0, 100, 0, 255},
+	"darkgrey":             {169, 169, 169, 255},
+	"darkkhaki":            {189, 183, 107, 255},
+	"darkmagenta":          {139, 0, 139, 255},
+	"darkolivegreen":       {85, 107, 47, 255},
+	"darkorange":           {255, 140, 0, 255},
+	"darkorchid":           {153, 50, 204, 255},
+	"darkred":              {139, 0, 0, 255},
+	"darksalmon":           {233, 150, 122, 255},
+	"darkseagreen":         {143, 188, 143, 255},
+	"darkslateblue":        {72, 61, 139, 255},
+	"darkslategray":        {47, 79, 79, 255},
+	"darkslategrey":        {47, 79, 79, 255},
+	"darkturquoise":        {0, 206, 209, 255},
+	"darkviolet":           {148, 0, 211, 255},
+	"deeppink":             {255, 20, 147, 255},
+	"deepskyblue":          {0, 191, 255, 255},
+	"dimgray":              {105, 105, 105, 255},
+	"dimgrey":              {105, 105, 105, 255},
+	"dodgerblue":           {30, 144, 255, 255},
+	"firebrick":            {178, 34, 34, 255},
+	"floralwhite":          {255, 250, 240, 255},
+	"forestgreen":          {34, 139, 34, 255},
+	"fuchsia":              {255, 0, 255, 255},
+	"gainsboro":            {220, 220, 220, 255},
+	"ghostwhite":           {248, 248, 255, 255},
+	"gold":                 {255, 215, 0, 255},
+	"goldenrod":            {218, 165, 32, 255},
+	"gray":                 {128, 128, 128, 255},
+	"green":                {0, 128, 0, 255},
+	"greenyellow":          {173, 255, 47, 255},
+	"grey":                 {128, 128, 128, 255},
+	"honeydew":             {240, 255, 240, 255},
+	"hotpink":              {255, 105, 180, 255},
+	"indianred":            {205, 92, 92, 255},
+	"indigo":               {75, 0, 130, 255},
+	"ivory":                {255, 255, 240, 255},
+	"khaki":                {240, 230, 140, 255},
+	"lavender":             {230, 230, 250, 255},
+	"lavenderblush":        {255, 240, 245, 255},
+	"lawngreen":            {124, 252, 0, 255},
+	"lemonchiffon":         {255, 250, 205, 255},
+	"lightblue":            {173, 216, 230, 255},
+	"lightcoral":           {240, 128, 128, 255},
+	"lightcyan":            {224, 255, 255, 255},
+	"lightgoldenrodyellow": {250, 250, 210, 255},
+	"lightgray":            {211, 211, 211, 255},
+	"lightgreen":           {144, 238, 144, 255},
+	"lightgrey":            {211, 211, 211, 255},
+	"lightpink":            {255, 182, 193, 255},
+	"lightsalmon":          {255, 160, 122, 255},
+	"lightseagreen":        {32, 178, 170, 255},
+	"lightskyblue":         {135, 206, 250, 255},
+	"lightslategray":       {119, 136, 153, 255},
+	"lightslategrey":       {119, 136, 153, 255},
+	"lightsteelblue":       {176, 196, 222, 255},
+	"lightyellow":          {255, 255, 224, 255},
+	"lime":                 {0, 255, 0, 255},
+	"limegreen":            {50, 205, 50, 255},
+	"linen":                {250, 240, 230, 255},
+	"magenta":              {255, 0, 255, 255},
+	"maroon":               {128, 0, 0, 255},
+	"mediumaquamarine":     {102, 205, 170, 255},
+	"mediumblue":           {0, 0, 205, 255},
+	"mediumorchid":         {186, 85, 211, 255},
+	"mediumpurple":         {147, 112, 219, 255},
+	"mediumseagreen":       {60, 179, 113, 255},
+	"mediumslateblue":      {123, 104, 238, 255},
+	"mediumspringgreen":    {0, 250, 154, 255},
+	"mediumturquoise":      {72, 209, 204, 255},
+	"mediumvioletred":      {199, 21, 133, 255},
+	"midnightblue":         {25, 25, 112, 255},
+	"mintcream":            {245, 255, 250, 255},
+	"mistyrose":            {255, 228, 225, 255},
+	"moccasin":             {255, 228, 181, 255},
+	"navajowhite":          {255, 222, 173, 255},
+	"navy":                 {0, 0, 128, 255},
+	"oldlace":              {253, 245, 230, 255},
+	"olive":                {128, 128, 0, 255},
+	"olivedrab":            {107, 142, 35, 255},
+	"orange":               {255, 165, 0, 255},
+	"orangered":            {255, 69, 0, 255},
+	"orchid":               {218, 112, 214, 255},
+	"palegoldenrod":        {238, 232, 170, 255},
+	"palegreen":            {152, 251, 152, 255},
+	"paleturquoise":        {175, 238, 238, 255},
+	"palevioletred":        {219, 112, 147, 255},
+	"papayawhip":           {255, 239, 213, 255},
+	"peachpuff":            {255, 218, 185, 255},
+	"peru":                 {205, 133, 63, 255},
+	"pink":                 {255, 192, 203, 255},
+	"plum":                 {221, 160, 221, 255},
+	"powderblue":           {176, 224, 230, 255},
+	"purple":               {128, 0, 128, 255},
+	"rebeccapurple":        {102, 51, 153, 255},
+	"red":                  {255, 0, 0, 255},
+	"rosybrown":            {188, 143, 143, 255},
+	"royalblue":            {65, 105, 225, 255},
+	"saddlebrown":          {139, 69, 19, 255},
+	"salmon":               {250, 128, 114, 255},
+	"sandybrown":           {244, 164, 96, 255},
+	"seagreen":             {46, 139, 87, 255},
+	"seashell":             {255, 245, 238, 255},
+	"sienna":               {160, 82, 45, 255},
+	"silver":               {192, 192, 192, 255},
+	"skyblue":              {135, 206, 235, 255},
+	"slateblue":            {106, 90, 205, 255},
+	"slategray":            {112, 128, 144, 255},
+	"slategrey":            {112, 128, 144, 255},
+	"snow":                 {255, 250, 250, 255},
+	"springgreen":          {0, 255, 127, 255},
+	"steelblue":            {70, 130, 180, 255},
+	"tan":                  {210, 180, 140, 255},
+	"teal":                 {0, 128, 128, 255},
+	"thistle":              {216, 191, 216, 255},
+	"tomato":               {255, 99, 71, 255},
+	"turquoise":            {64, 224, 208, 255},
+	"violet":               {238, 130, 238, 255},
+	"wheat":                {245, 222, 179, 255},
+	"white":                {255, 255, 255, 255},
+	"whitesmoke":           {245, 245, 245, 255},
+	"yellow":               {255, 255, 0, 255},
+	"yellowgreen":          {154, 205, 50, 255},
+	"transparent":          {0, 0, 0, 0},
+}
+
+// colorPalettes are named sets of hex colors, served as
+// omni://colors/palettes/{name} for clients to feed into analyze_color.
+var colorPalettes = map[string][]string{
+	"grayscale": {"#000000", "#333333", "#666666", "#999999", "#cccccc", "#ffffff"},
+	"rainbow":   {"#ff0000", "#ff7f00", "#ffff00", "#00ff00", "#0000ff", "#4b0082", "#9400d3"},
+	"web-safe":  {"#000000", "#0000ff", "#00ff00", "#00ffff", "#ff0000", "#ff00ff", "#ffff00", "#ffffff"},
+}
+
+// toolAnalyzeColor parses any supported color syntax and reports every format plus accessibility info.
+func toolAnalyzeColor(ctx context.Context, input string) (interface{}, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	r, g, b, a := 0, 0, 0, 255 // alpha defaults to 255 (fully opaque)
+	hasAlpha := false
+	parsed := false
+
+	// 1. Parsing Logic
+	hexInput := strings.TrimPrefix(input, "#")
+
+	// Hex formats: #RGB, #RGBA, #RRGGBB, #RRGGBBAA
+	if strings.HasPrefix(input, "#") || (len(hexInput) >= 3 && isHex(hexInput)) {
+		switch len(hexInput) {
+		case 3: // #RGB
+			fmt.Sscanf(hexInput, "%1x%1x%1x", &r, &g, &b)
+			r, g, b = r*17, g*17, b*17 // expand to full range
+			parsed = true
+		case 4: // #RGBA
+			fmt.Sscanf(hexInput, "%1x%1x%1x%1x", &r, &g, &b, &a)
+			r, g, b, a = r*17, g*17, b*17, a*17
+			hasAlpha = true
+			parsed = true
+		case 6: // #RRGGBB
+			fmt.Sscanf(hexInput, "%02x%02x%02x", &r, &g, &b)
+			parsed = true
+		case 8: // #RRGGBBAA
+			fmt.Sscanf(hexInput, "%02x%02x%02x%02x", &r, &g, &b, &a)
+			hasAlpha = true
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "rgba") {
+		// rgba(r, g, b, a)
+		re := regexp.MustCompile(`[\d.]+`)
+		matches := re.FindAllString(input, 4)
+		if len(matches) >= 4 {
+			r, _ = strconv.Atoi(matches[0])
+			g, _ = strconv.Atoi(matches[1])
+			b, _ = strconv.Atoi(matches[2])
+			alphaF, _ := strconv.ParseFloat(matches[3], 64)
+			if alphaF <= 1.0 {
+				a = int(alphaF * 255)
+			} else {
+				a = int(alphaF)
+			}
+			hasAlpha = true
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "rgb") {
+		// rgb(r, g, b)
+		re := regexp.MustCompile(`\d+`)
+		matches := re.FindAllString(input, 3)
+		if len(matches) == 3 {
+			r, _ = strconv.Atoi(matches[0])
+			g, _ = strconv.Atoi(matches[1])
+			b, _ = strconv.Atoi(matches[2])
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "hsl") {
+		// hsl(h, s%, l%) or hsla(h, s%, l%, a)
+		re := regexp.MustCompile(`[\d.]+`)
+		matches := re.FindAllString(input, 4)
+		if len(matches) >= 3 {
+			hue, _ := strconv.ParseFloat(matches[0], 64)
+			sat, _ := strconv.ParseFloat(matches[1], 64)
+			lit, _ := strconv.ParseFloat(matches[2], 64)
+			r, g, b = hslToRGB(hue, sat/100, lit/100)
+			if len(matches) >= 4 {
+				alphaF, _ := strconv.ParseFloat(matches[3], 64)
+				if alphaF <= 1.0 {
+					a = int(alphaF * 255)
+				} else {
+					a = int(alphaF)
+				}
+				hasAlpha = true
+			}
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "hwb") {
+		// hwb(h w% b%) or hwb(h w% b% / a)
+		re := regexp.MustCompile(`[\d.]+`)
+		matches := re.FindAllString(input, 4)
+		if len(matches) >= 3 {
+			hue, _ := strconv.ParseFloat(matches[0], 64)
+			white, _ := strconv.ParseFloat(matches[1], 64)
+			black, _ := strconv.ParseFloat(matches[2], 64)
+			r, g, b = hwbToRGB(hue, white/100, black/100)
+			if len(matches) >= 4 {
+				alphaF, _ := strconv.ParseFloat(matches[3], 64)
+				if alphaF <= 1.0 {
+					a = int(alphaF * 255)
+				} else {
+					a = int(alphaF)
+				}
+				hasAlpha = true
+			}
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "lab") {
+		// lab(l a b) or lab(l a b / alpha)
+		re := regexp.MustCompile(`-?[\d.]+`)
+		matches := re.FindAllString(input, 4)
+		if len(matches) >= 3 {
+			L, _ := strconv.ParseFloat(matches[0], 64)
+			A, _ := strconv.ParseFloat(matches[1], 64)
+			B, _ := strconv.ParseFloat(matches[2], 64)
+			r, g, b = labToRGB(L, A, B)
+			if len(matches) >= 4 {
+				alphaF, _ := strconv.ParseFloat(matches[3], 64)
+				if alphaF <= 1.0 {
+					a = int(alphaF * 255)
+				} else {
+					a = int(alphaF)
+				}
+				hasAlpha = true
+			}
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "lch") {
+		// lch(l c h) or lch(l c h / alpha)
+		re := regexp.MustCompile(`-?[\d.]+`)
+		matches := re.FindAllString(input, 4)
+		if len(matches) >= 3 {
+			L, _ := strconv.ParseFloat(matches[0], 64)
+			C, _ := strconv.ParseFloat(matches[1], 64)
+			H, _ := strconv.ParseFloat(matches[2], 64)
+			r, g, b = lchToRGB(L, C, H)
+			if len(matches) >= 4 {
+				alphaF, _ := strconv.ParseFloat(matches[3], 64)
+				if alphaF <= 1.0 {
+					a = int(alphaF * 255)
+				} else {
+					a = int(alphaF)
+				}
+				hasAlpha = true
+			}
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "oklch") {
+		// oklch(l c h) or oklch(l c h / alpha)
+		re := regexp.MustCompile(`-?[\d.]+`)
+		matches := re.FindAllString(input, 4)
+		if len(matches) >= 3 {
+			L, _ := strconv.ParseFloat(matches[0], 64)
+			C, _ := strconv.ParseFloat(matches[1], 64)
+			H, _ := strconv.ParseFloat(matches[2], 64)
+			r, g, b = oklchToRGB(L, C, H)
+			if len(matches) >= 4 {
+				alphaF, _ := strconv.ParseFloat(matches[3], 64)
+				if alphaF <= 1.0 {
+					a = int(alphaF * 255)
+				} else {
+					a = int(alphaF)
+				}
+				hasAlpha = true
+			}
+			parsed = true
+		}
+	} else if strings.HasPrefix(input, "oklab") {
+		// oklab(l a b) or oklab(l a b / alpha)
+		re := regexp.MustCompile(`-?[\d.]+`)
+		matches := re.FindAllString(input, 4)
+		if len(matches) >= 3 {
+			L, _ := strconv.ParseFloat(matches[0], 64)
+			A, _ := strconv.ParseFloat(matches[1], 64)
+			B, _ := strconv.ParseFloat(matches[2], 64)
+			r, g, b = oklabToRGB(L, A, B)
+			if len(matches) >= 4 {
+				alphaF, _ := strconv.ParseFloat(matches[3], 64)
+				if alphaF <= 1.0 {
+					a = int(alphaF * 255)
+				} else {
+					a = int(alphaF)
+				}
+				hasAlpha = true
+			}
+			parsed = true
+		}
+	} else {
+		// Named colors fallback
+		if c, ok := namedColors[input]; ok {
+			r, g, b, a = c[0], c[1], c[2], c[3]
+			hasAlpha = a != 255
+			parsed = true
+		}
+	}
+
+	if !parsed {
+		return nil, toolErrorf(KindParseFailure, "Could not parse color: %s", input)
+	}
+
+	// Clamp values
+	clamp := func(x int) int {
+		if x < 0 {
+			return 0
+		}
+		if x > 255 {
+			return 255
+		}
+		return x
+	}
+	r, g, b, a = clamp(r), clamp(g), clamp(b), clamp(a)
+	alphaFloat := float64(a) / 255.0
+
+	// 2. Calculations
+	// HSL & HSV
+	h, s, l := rgbToHSL(r, g, b)
+	_, sv, v := rgbToHSV(r, g, b)
+
+	// HWB
+	hwbH, hwbW, hwbB := rgbToHWB(r, g, b)
+
+	// CMYK
+	c, m, y, k := rgbToCMYK(r, g, b)
+
+	// LAB & LCH
+	labL, labA, labB := rgbToLAB(r, g, b)
+	lchL, lchC, lchH := rgbToLCH(r, g, b)
+
+	// Oklab & Oklch
+	okL, okA, okB := rgbToOklab(r, g, b)
+	oklchL, oklchC, oklchH := rgbToOklch(r, g, b)
+
+	// Luminance & Contrast
+	lum := 0.2126*float64(r)/255.0 + 0.7152*float64(g)/255.0 + 0.0722*float64(b)/255.0
+	contrastWhite := (1.0 + 0.05) / (lum + 0.05)
+	contrastBlack := (lum + 0.05) / (0.0 + 0.05)
+
+	// Ansi 256 approximation
+	ansi := 16 + (36 * (r / 51)) + (6 * (g / 51)) + (b / 51)
+	if r == g && g == b {
+		if r < 8 {
+			ansi = 16
+		} else if r > 248 {
+			ansi = 231
+		} else {
+			ansi = 232 + (((r - 8) * 24) / 247)
+		}
+	}
+
+	// Build formats map
+	formats := map[string]interface{}{
+		"hex":       strings.ToUpper(fmt.Sprintf("#%02x%02x%02x", r, g, b)),
+		"rgb":       map[string]int{"r": r, "g": g, "b": b},
+		"rgb_css":   fmt.Sprintf("rgb(%d, %d, %d)", r, g, b),
+		"hsl":       map[string]interface{}{"h": round(h), "s": round(s * 100), "l": round(l * 100)},
+		"hsl_css":   fmt.Sprintf("hsl(%.0f, %.0f%%, %.0f%%)", h, s*100, l*100),
+		"hsv":       map[string]interface{}{"h": round(h), "s": round(sv * 100), "v": round(v * 100)},
+		"hwb":       map[string]interface{}{"h": round(hwbH), "w": round(hwbW), "b": round(hwbB)},
+		"hwb_css":   fmt.Sprintf("hwb(%.0f %.0f%% %.0f%%)", hwbH, hwbW, hwbB),
+		"cmyk":      map[string]interface{}{"c": round(c * 100), "m": round(m * 100), "y": round(y * 100), "k": round(k * 100)},
+		"cmyk_css":  fmt.Sprintf("cmyk(%.0f%%, %.0f%%, %.0f%%, %.0f%%)", c*100, m*100, y*100, k*100),
+		"lab":       map[string]interface{}{"l": roundDig(labL, 2), "a": roundDig(labA, 2), "b": roundDig(labB, 2)},
+		"lab_css":   fmt.Sprintf("lab(%.2f %.2f %.2f)", labL, labA, labB),
+		"lch":       map[string]interface{}{"l": roundDig(lchL, 2), "c": roundDig(lchC, 2), "h": roundDig(lchH, 2)},
+		"lch_css":   fmt.Sprintf("lch(%.2f %.2f %.2f)", lchL, lchC, lchH),
+		"oklab":     map[string]interface{}{"l": roundDig(okL, 4), "a": roundDig(okA, 4), "b": roundDig(okB, 4)},
+		"oklab_css": fmt.Sprintf("oklab(%.4f %.4f %.4f)", okL, okA, okB),
+		"oklch":     map[string]interface{}{"l": roundDig(oklchL, 4), "c": roundDig(oklchC, 4), "h": roundDig(oklchH, 2)},
+		"oklch_css": fmt.Sprintf("oklch(%.4f %.4f %.2f)", oklchL, oklchC, oklchH),
+		"ansi256":   ansi,
+	}
+
+	// Add alpha formats if alpha channel is present
+	if hasAlpha {
+		formats["alpha"] = roundDig(alphaFloat, 3)
+		formats["alpha_percent"] = round(alphaFloat * 100)
+		formats["hexa"] = strings.ToUpper(fmt.Sprintf("#%02x%02x%02x%02x", r, g, b, a))
+		formats["rgba"] = map[string]interface{}{"r": r, "g": g, "b": b, "a": roundDig(alphaFloat, 3)}
+		formats["rgba_css"] = fmt.Sprintf("rgba(%d, %d, %d, %.3f)", r, g, b, alphaFloat)
+		formats["hsla_css"] = fmt.Sprintf("hsla(%.0f, %.0f%%, %.0f%%, %.3f)", h, s*100, l*100, alphaFloat)
+		formats["hwb_css"] = fmt.Sprintf("hwb(%.0f %.0f%% %.0f%% / %.3f)", hwbH, hwbW, hwbB, alphaFloat)
+		formats["lab_css"] = fmt.Sprintf("lab(%.2f %.2f %.2f / %.3f)", labL, labA, labB, alphaFloat)
+		formats["lch_css"] = fmt.Sprintf("lch(%.2f %.2f %.2f / %.3f)", lchL, lchC, lchH, alphaFloat)
+		formats["oklab_css"] = fmt.Sprintf("oklab(%.4f %.4f %.4f / %.3f)", okL, okA, okB, alphaFloat)
+		formats["oklch_css"] = fmt.Sprintf("oklch(%.4f %.4f %.2f / %.3f)", oklchL, oklchC, oklchH, alphaFloat)
+	}
+
+	return &ColorAnalysis{
+		OriginalInput: input,
+		HasAlpha:      hasAlpha,
+		Formats:       formats,
+		Accessibility: ColorAccessibility{
+			Luminance:       roundDig(lum, 4),
+			ContrastWhite:   roundDig(contrastWhite, 2),
+			ContrastBlack:   roundDig(contrastBlack, 2),
+			WCAGAACompliant: contrastWhite >= 4.5 || contrastBlack >= 4.5,
+			RecommendedTextColor: func() string {
+				if contrastBlack > contrastWhite {
+					return "black"
+				} else {
+					return "white"
+				}
+			}(),
+		},
+	}, nil
+}
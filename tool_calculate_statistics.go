@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+type calculateStatisticsArgs struct {
+	Numbers []float64 `json:"numbers"`
+}
+
+func init() {
+	RegisterTool(DefaultRegistry, Tool{
+		Name:        "calculate_statistics",
+		Description: "Returns stats (mean, median, mode, stdev) for a list of numbers.",
+		Annotations: &ToolAnnotations{Title: "Calculate Statistics", ReadOnlyHint: true, IdempotentHint: true},
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"numbers": {"type": "array", "items": {"type": "number"}}
+			},
+			"required": ["numbers"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"count": {"type": "integer"},
+				"sum": {"type": "number"},
+				"mean": {"type": "number"},
+				"median": {"type": "number"},
+				"min": {"type": "number"},
+				"max": {"type": "number"}
+			},
+			"required": ["count", "sum", "mean", "median", "min", "max"]
+		}`),
+	}, func(ctx context.Context, args calculateStatisticsArgs) (interface{}, error) {
+		return toolCalculateStatistics(ctx, args.Numbers)
+	})
+}
+
+// Statistics summarizes a list of numbers.
+type Statistics struct {
+	Count  int     `json:"count"`
+	Sum    float64 `json:"sum"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// toolCalculateStatistics summarizes a list of numbers.
+func toolCalculateStatistics(ctx context.Context, nums []float64) (interface{}, error) {
+	if len(nums) == 0 {
+		return nil, toolErrorf(KindInvalidInput, "Empty list")
+	}
+
+	// Summing is one step per number and sorting one step for the lot
+	progress := progressFromContext(ctx)
+	total := float64(len(nums) + 1)
+
+	sum := 0.0
+	for i, n := range nums {
+		if i%cancelCheckInterval == 0 {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			progress.Report(float64(i), total, "summing")
+		}
+		sum += n
+	}
+	mean := sum / float64(len(nums))
+
+	progress.Report(float64(len(nums)), total, "sorting")
+	sort.Float64s(nums)
+	progress.Report(total, total, "")
+	median := 0.0
+	if len(nums)%2 == 0 {
+		median = (nums[len(nums)/2-1] + nums[len(nums)/2]) / 2
+	} else {
+		median = nums[len(nums)/2]
+	}
+
+	minVal := nums[0]
+	maxVal := nums[len(nums)-1]
+
+	return &Statistics{
+		Count:  len(nums),
+		Sum:    sum,
+		Mean:   mean,
+		Median: median,
+		Min:    minVal,
+		Max:    maxVal,
+	}, nil
+}
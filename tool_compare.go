@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+type compareArgs struct {
+	ValueA string `json:"value_a"`
+	UnitA  string `json:"unit_a"`
+	ValueB string `json:"value_b"`
+	UnitB  string `json:"unit_b"`
+}
+
+func init() {
+	RegisterTool(DefaultRegistry, Tool{
+		Name:        "compare",
+		Description: "Compares two values, handling unit conversions (e.g., 10km vs 5miles) and types.",
+		Annotations: &ToolAnnotations{Title: "Compare Values", ReadOnlyHint: true, IdempotentHint: true},
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"value_a": {"type": "string"},
+				"unit_a": {"type": "string", "description": "Unit for value A (optional)"},
+				"value_b": {"type": "string"},
+				"unit_b": {"type": "string", "description": "Unit for value B (optional)"}
+			},
+			"required": ["value_a", "value_b"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"oneOf": [
+				{
+					"properties": {
+						"type": {"const": "physical_comparison"},
+						"category": {"type": "string"},
+						"normalized_base_diff": {"type": "number"},
+						"percent_diff_a_relative_to_b": {"type": "number"},
+						"a_greater": {"type": "boolean"},
+						"inputs": {
+							"type": "object",
+							"properties": {"a": {"type": "string"}, "b": {"type": "string"}},
+							"required": ["a", "b"]
+						},
+						"exact_base_diff": {"type": "string"}
+					},
+					"required": ["type", "category", "normalized_base_diff", "percent_diff_a_relative_to_b", "a_greater", "inputs"]
+				},
+				{
+					"properties": {
+						"type": {"const": "numeric"},
+						"diff": {"type": "number"},
+						"a_greater": {"type": "boolean"},
+						"exact_diff": {"type": "string"}
+					},
+					"required": ["type", "diff", "a_greater"]
+				},
+				{
+					"properties": {
+						"type": {"const": "string"},
+						"levenshtein": {"type": "integer"},
+						"similarity_percent": {"type": "number"}
+					},
+					"required": ["type", "levenshtein", "similarity_percent"]
+				}
+			]
+		}`),
+	}, func(ctx context.Context, args compareArgs) (interface{}, error) {
+		return toolCompare(ctx, args.ValueA, args.UnitA, args.ValueB, args.UnitB)
+	})
+}
+
+// PhysicalComparison compares two quantities after converting both to
+// the base unit of their shared category.
+type PhysicalComparison struct {
+	Type                    string            `json:"type"` // always "physical_comparison"
+	Category                string            `json:"category"`
+	NormalizedBaseDiff      float64           `json:"normalized_base_diff"`
+	PercentDiffARelativeToB float64           `json:"percent_diff_a_relative_to_b"`
+	AGreater                bool              `json:"a_greater"`
+	Inputs                  map[string]string `json:"inputs"`
+	ExactDiff               string            `json:"exact_base_diff,omitempty"` // crypto and digital
+}
+
+// NumericComparison compares two plain numbers.
+type NumericComparison struct {
+	Type      string  `json:"type"` // always "numeric"
+	Diff      float64 `json:"diff"`
+	AGreater  bool    `json:"a_greater"`
+	ExactDiff string  `json:"exact_diff,omitempty"` // the difference as an exact decimal, so large integers compare correctly
+}
+
+// StringComparison compares two strings by edit distance.
+type StringComparison struct {
+	Type              string  `json:"type"` // always "string"
+	Levenshtein       int     `json:"levenshtein"`
+	SimilarityPercent float64 `json:"similarity_percent"`
+}
+
+// toolCompare normalizes values with compatible units before comparing them.
+func toolCompare(ctx context.Context, valA string, unitA string, valB string, unitB string) (interface{}, error) {
+	// If units are present, try to normalize
+	if unitA != "" && unitB != "" && (isDimensionalUnit(unitA) || isDimensionalUnit(unitB)) {
+		if res, ok, err := compareDimensional(valA, unitA, valB, unitB); ok || err != nil {
+			return res, err
+		}
+	} else if unitA != "" && unitB != "" {
+		catA := inferCategory(unitA)
+		catB := inferCategory(unitB)
+
+		if catA == catB && catA != "" && catA != "color" {
+			// Compatible physical units
+			fA, errA := strconv.ParseFloat(valA, 64)
+			fB, errB := strconv.ParseFloat(valB, 64)
+
+			if errA == nil && errB == nil {
+				if err := checkFinite(valA, fA); err != nil {
+					return nil, err
+				}
+				if err := checkFinite(valB, fB); err != nil {
+					return nil, err
+				}
+				baseA, uA, err := getBaseValue(fA, unitA, catA)
+				if err != nil {
+					return nil, err
+				}
+				baseB, uB, err := getBaseValue(fB, unitB, catB)
+				if err != nil {
+					return nil, err
+				}
+				if uA.System != uB.System {
+					if c, _ := findCategory(catA); c.Systems == systemsExclusive {
+						return nil, toolErrorf(KindUnsupportedUnit, "Cannot compare %s with %s: %s and %s units do not convert", unitA, unitB, uA.System, uB.System)
+					}
+				}
+
+				diff := baseA - baseB
+				pct := 0.0
+				if baseB != 0 {
+					pct = (diff / baseB) * 100
+				}
+				if err := checkFinite(valA+" "+unitA+" - "+valB+" "+unitB, diff, pct); err != nil {
+					return nil, err
+				}
+
+				res := &PhysicalComparison{
+					Type:                    "physical_comparison",
+					Category:                catA,
+					NormalizedBaseDiff:      diff,
+					PercentDiffARelativeToB: pct,
+					AGreater:                baseA > baseB,
+					Inputs: map[string]string{
+						"a": fmt.Sprintf("%v %s", valA, unitA),
+						"b": fmt.Sprintf("%v %s", valB, unitB),
+					},
+				}
+				// Hex floats and the like have no exact form and keep the
+				// float comparison
+				rA, errRA := parseRat(valA)
+				rB, errRB := parseRat(valB)
+				if err := ratRangeError(errRA, errRB); err != nil {
+					return nil, err
+				}
+				if c, _ := findCategory(catA); c.Exact && errRA == nil && errRB == nil {
+					d := new(big.Rat).Sub(rA.Mul(rA, uA.ratFactor(nil)), rB.Mul(rB, uB.ratFactor(nil)))
+					res.ExactDiff = ratString(d)
+					res.AGreater = d.Sign() > 0
+				}
+				return res, nil
+			}
+		}
+	}
+
+	// Default to generic compare (numeric, string, color)
+	return toolCompareValues(ctx, valA, valB) // Reuse existing logic
+}
+
+// compareDimensional compares two quantities given as unit expressions.
+// ok is false when either side is not a number with a valid unit, leaving
+// the values to the generic comparison.
+func compareDimensional(valA, unitA, valB, unitB string) (res interface{}, ok bool, err error) {
+	fA, errA := strconv.ParseFloat(valA, 64)
+	fB, errB := strconv.ParseFloat(valB, 64)
+	qA, errQA := parseUnitExpr(unitA)
+	qB, errQB := parseUnitExpr(unitB)
+	if errA != nil || errB != nil || errQA != nil || errQB != nil {
+		return nil, false, nil
+	}
+	if qA.Dim != qB.Dim {
+		return nil, false, toolErrorf(KindUnsupportedUnit, "Cannot compare %s (%s) with %s (%s): dimensions differ", unitA, qA.Dim, unitB, qB.Dim)
+	}
+	category, _, found := findDerivedQuantity(qA.Dim)
+	if !found {
+		category = "derived"
+	}
+	baseA, baseB := fA*qA.Factor, fB*qB.Factor
+	diff := baseA - baseB
+	pct := 0.0
+	if baseB != 0 {
+		pct = (diff / baseB) * 100
+	}
+	if err := checkFinite(valA+" "+unitA+" - "+valB+" "+unitB, diff, pct); err != nil {
+		return nil, true, err
+	}
+	return &PhysicalComparison{
+		Type:                    "physical_comparison",
+		Category:                category,
+		NormalizedBaseDiff:      diff,
+		PercentDiffARelativeToB: pct,
+		AGreater:                baseA > baseB,
+		Inputs: map[string]string{
+			"a": fmt.Sprintf("%v %s", valA, unitA),
+			"b": fmt.Sprintf("%v %s", valB, unitB),
+		},
+	}, true, nil
+}
+
+// toolCompareValues compares two plain values numerically or by string similarity.
+func toolCompareValues(ctx context.Context, a, b string) (interface{}, error) {
+	// Numeric
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+
+	if errA == nil && errB == nil {
+		if err := checkFinite(a, fa); err != nil {
+			return nil, err
+		}
+		if err := checkFinite(b, fb); err != nil {
+			return nil, err
+		}
+		res := &NumericComparison{
+			Type:     "numeric",
+			Diff:     fa - fb,
+			AGreater: fa > fb,
+		}
+		// Compare exactly where possible: 2^53+1 and 2^53 are equal as float64
+		ra, errRA := parseRat(a)
+		rb, errRB := parseRat(b)
+		if err := ratRangeError(errRA, errRB); err != nil {
+			return nil, err
+		}
+		if errRA == nil && errRB == nil {
+			diff := new(big.Rat).Sub(ra, rb)
+			res.ExactDiff = ratString(diff)
+			res.AGreater = diff.Sign() > 0
+		}
+		return res, nil
+	}
+
+	// String similarity (Levenshtein)
+	dist, err := levenshteinContext(ctx, a, b)
+	if err != nil {
+		return nil, err
+	}
+	maxLen := math.Max(float64(len(a)), float64(len(b)))
+	sim := 0.0
+	if maxLen > 0 {
+		sim = (1.0 - float64(dist)/maxLen) * 100
+	}
+
+	return &StringComparison{
+		Type:              "string",
+		Levenshtein:       dist,
+		SimilarityPercent: sim,
+	}, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type convertArgs struct {
+	Value    string `json:"value"`
+	Unit     string `json:"unit"`
+	To       string `json:"to"`
+	Decimals *int   `json:"decimals"`
+	AsOf     string `json:"as_of"`
+	Locale   string `json:"locale"`
+}
+
+func init() {
+	RegisterTool(DefaultRegistry, Tool{
+		Name:        "convert",
+		Description: "Universal converter for Time, Color, Currency, and Physical Units (Length, Weight, Temp, Digital, CSS).",
+		Annotations: &ToolAnnotations{Title: "Convert", ReadOnlyHint: true, IdempotentHint: true},
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"value": {"type": "string", "description": "The value to convert (e.g., '10', 'now', '#FF0000', '1690000000'); quantities may carry their unit ('3.2 kg', '1,024 MiB', '72°F', '5 ft 11 in')"},
+				"unit": {"type": "string", "description": "The source unit or context (e.g., 'km', 'lbs', 'iso', 'hex', 'rgb', or 'duration' for ISO 8601, Go and spelled-out durations with + and -), or for times the zone of the input and output (e.g., 'America/New_York', 'PST', '+05:30')"},
+				"to": {"type": "string", "description": "Target unit; returns a single result instead of every unit of the category (e.g., 'cm', 'kWh', 'L/100km'), or the zone to render a time in"},
+				"decimals": {"type": "integer", "minimum": 0, "maximum": 77, "description": "Decimals of the token for raw token amounts (e.g., 6 with 'erc20_raw'); defaults to the token's own"},
+				"as_of": {"type": "string", "description": "Date of the exchange rates for currencies (e.g., '2024-01-31'); the latest table on or before it is used"},
+				"locale": {"type": "string", "description": "Locale of a date: the language of its month names and whether numeric dates put the day or month first (e.g., 'en-US', 'en-GB', 'de', 'fr'); defaults to en-US"}
+			},
+			"required": ["value"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"oneOf": [` + unitConversionSchema + `, ` + quantityConversionSchema + `, ` + currencyConversionSchema + `, ` + timeConversionSchema + `, ` + durationConversionSchema + `, ` + colorAnalysisSchema + `]
+		}`),
+	}, func(ctx context.Context, args convertArgs) (interface{}, error) {
+		return toolConvert(ctx, args)
+	})
+}
+
+// toolConvert routes a value to color, unit, currency, duration or time
+// conversion based on its unit. A non-empty To asks for that one unit only; Decimals,
+// if set, overrides the decimals of a token.
+func toolConvert(ctx context.Context, args convertArgs) (interface{}, error) {
+	valStr, unitStr, to, decimals := args.Value, args.Unit, args.To, args.Decimals
+
+	// 1. Check if unit implies a category
+	category := inferCategory(unitStr)
+
+	if category == "color" {
+		if to != "" {
+			return nil, toolErrorf(KindInvalidInput, "'to' applies to unit conversions, not colors")
+		}
+		return toolAnalyzeColor(ctx, valStr)
+	}
+
+	// A zone as unit reads wall-clock times there; a zone as to renders
+	// there, whatever format hint such as "iso" the unit gives
+	physical := category != "" || isDimensionalUnit(unitStr) || isCurrency(unitStr) || strings.EqualFold(strings.TrimSpace(unitStr), "duration")
+	if isTimeZone(unitStr) || (!physical && isTimeZone(to)) {
+		return toolConvertTime(valStr, unitStr, to, args.Locale)
+	}
+
+	// Durations: unit "duration", or a value that can only be one such as
+	// P1DT2H or 1h30m + 45m
+	if strings.EqualFold(strings.TrimSpace(unitStr), "duration") || (strings.TrimSpace(unitStr) == "" && isDurationExpr(valStr)) {
+		if to != "" {
+			return nil, toolErrorf(KindInvalidInput, "'to' applies to unit conversions, not durations; every notation is returned")
+		}
+		return toolConvertDuration(valStr)
+	}
+
+	// Free text such as "3.2 kg" or 5'11" carries its own unit, unless it
+	// reads as a date such as "2 Jan"
+	_, relative := parseRelativeTime(valStr, time.Now())
+	if _, _, _, date := detectDate(valStr, time.UTC, args.Locale); !relative && !date {
+		if q, ok := parseQuantityText(valStr); ok && q.Unit != "" {
+			if !q.Known {
+				return nil, toolErrorf(KindUnsupportedUnit, "Unknown unit %q in value %q", q.Unit, valStr)
+			}
+			if unitStr != "" && !sameUnit(unitStr, q.Unit) {
+				return nil, toolErrorf(KindInvalidInput, "value %q is in %s but unit is %q", valStr, q.Unit, unitStr)
+			}
+			valStr, unitStr = q.Text, q.Unit
+			category = inferCategory(unitStr)
+		}
+	}
+
+	if isCurrency(unitStr) {
+		if decimals != nil {
+			return nil, toolErrorf(KindInvalidInput, "decimals applies to token amounts such as erc20_raw")
+		}
+		return toolConvertCurrency(valStr, unitStr, to, args.AsOf)
+	}
+	if args.AsOf != "" {
+		return nil, toolErrorf(KindInvalidInput, "as_of applies to currency conversions only")
+	}
+
+	if decimals != nil && category != "crypto" {
+		return nil, toolErrorf(KindInvalidInput, "decimals applies to token amounts such as erc20_raw")
+	}
+
+	if to != "" {
+		if strings.TrimSpace(unitStr) == "" {
+			return nil, toolErrorf(KindInvalidInput, "'to' needs a source unit, in 'unit' or in the value")
+		}
+		return convertTo(valStr, unitStr, to, decimals)
+	}
+
+	// Compound expressions and SI symbols go through dimensional analysis
+	if isDimensionalUnit(unitStr) {
+		q, err := parseUnitExpr(unitStr)
+		if err == nil {
+			val, pErr := strconv.ParseFloat(valStr, 64)
+			if pErr == nil {
+				return toolConvertDimensional(val, strings.TrimSpace(unitStr), q)
+			}
+			logToClient(ctx, "warning", "convert", "value %q is not a number, ignoring unit %q and parsing it as a time", valStr, unitStr)
+		} else {
+			logToClient(ctx, "info", "convert", "unit %q is not a valid unit expression (%v), parsing value %q as a time", unitStr, err, valStr)
+		}
+		return toolConvertTime(valStr, unitStr, "", args.Locale)
+	}
+
+	// 2. If it's a known physical unit, use numeric conversion
+	if category != "" {
+		if c, _ := findCategory(category); c.Exact {
+			if _, err := parseRat(valStr); err != errNotDecimal {
+				return toolConvertExact(valStr, unitStr, category, decimals)
+			}
+		}
+		val, err := strconv.ParseFloat(valStr, 64)
+		if err == nil {
+			return toolConvertUnits(val, unitStr, category)
+		}
+		logToClient(ctx, "warning", "convert", "value %q is not a number, ignoring %s unit %q and parsing it as a time", valStr, category, unitStr)
+	} else if unitStr != "" {
+		logToClient(ctx, "info", "convert", "unit %q is not a known unit, parsing value %q as a time", unitStr, valStr)
+	}
+
+	// 3. Fallback: Treat as Time
+	return toolConvertTime(valStr, unitStr, "", args.Locale)
+}
+
+// sameUnit reports whether two spellings name the same unit, such as "lb"
+// and "lbs".
+func sameUnit(a, b string) bool {
+	if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+		return true
+	}
+	ua, okA := lookupUnit(normalizeUnitText(strings.TrimSpace(a)))
+	ub, okB := lookupUnit(b)
+	return okA && okB && ua == ub
+}
+
+// Helper: Infer category from unit string
+func inferCategory(unit string) string {
+	if u, ok := lookupUnit(unit); ok {
+		return u.Category
+	}
+	if containsString(colorKeywordUnits, strings.ToLower(strings.TrimSpace(unit))) {
+		return "color"
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+type inspectJWTArgs struct {
+	Token string `json:"token"`
+}
+
+func init() {
+	RegisterTool(DefaultRegistry, Tool{
+		Name:        "inspect_jwt",
+		Description: "Decodes a JWT header & payload without verification.",
+		Annotations: &ToolAnnotations{Title: "Inspect JWT", ReadOnlyHint: true, IdempotentHint: true},
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"token": {"type": "string"}
+			},
+			"required": ["token"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"header": {"description": "Decoded JOSE header, null if it is not valid base64url JSON"},
+				"payload": {"description": "Decoded claims, null if they are not valid base64url JSON"}
+			},
+			"required": ["header", "payload"]
+		}`),
+	}, func(ctx context.Context, args inspectJWTArgs) (interface{}, error) {
+		return toolInspectJWT(ctx, args.Token)
+	})
+}
+
+// JWTInspection holds the decoded, unverified parts of a JWT.
+type JWTInspection struct {
+	Header  interface{} `json:"header"`
+	Payload interface{} `json:"payload"`
+}
+
+// toolInspectJWT decodes a JWT header and payload without verifying the signature.
+func toolInspectJWT(ctx context.Context, token string) (interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, toolErrorf(KindParseFailure, "Invalid JWT format: expected 3 dot-separated segments, got %d", len(parts))
+	}
+
+	decode := func(part, s string) interface{} {
+		// JWT uses RawURLEncoding (no padding)
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			logToClient(ctx, "warning", "inspect_jwt", "JWT %s is not valid base64url: %v", part, err)
+			return nil
+		}
+		var out interface{}
+		if err := json.Unmarshal(b, &out); err != nil {
+			logToClient(ctx, "warning", "inspect_jwt", "JWT %s is not valid JSON: %v", part, err)
+		}
+		return out
+	}
+
+	return &JWTInspection{
+		Header:  decode("header", parts[0]),
+		Payload: decode("payload", parts[1]),
+	}, nil
+}
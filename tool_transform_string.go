@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+type transformStringArgs struct {
+	Text string `json:"text"`
+}
+
+func init() {
+	RegisterTool(DefaultRegistry, Tool{
+		Name:        "transform_string",
+		Description: "Takes ANY string, detects encoding (Base64/Hex/JSON), returns decoded values and transformations.",
+		Annotations: &ToolAnnotations{Title: "Transform String", ReadOnlyHint: true, IdempotentHint: true},
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"text": {"type": "string"}
+			},
+			"required": ["text"]
+		}`),
+		OutputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"original": {"type": "string"},
+				"analysis": {
+					"type": "object",
+					"properties": {
+						"length": {"type": "integer"},
+						"detected_types": {"type": "array", "items": {"enum": ["base64", "url", "hex", "json"]}}
+					},
+					"required": ["length", "detected_types"]
+				},
+				"decodings": {"type": "object", "description": "Decoded value for each detected type"},
+				"transformations": {"type": "object", "additionalProperties": {"type": "string"}}
+			},
+			"required": ["original", "analysis", "decodings", "transformations"]
+		}`),
+	}, func(ctx context.Context, args transformStringArgs) (interface{}, error) {
+		return toolTransformString(ctx, args.Text)
+	})
+}
+
+// StringTransform lists what a string decodes to and common encodings of it.
+type StringTransform struct {
+	Original        string                 `json:"original"`
+	Analysis        StringAnalysis         `json:"analysis"`
+	Decodings       map[string]interface{} `json:"decodings"`
+	Transformations map[string]string      `json:"transformations"`
+}
+
+type StringAnalysis struct {
+	Length        int      `json:"length"`
+	DetectedTypes []string `json:"detected_types"`
+}
+
+// toolTransformString detects encodings in text and lists common transformations.
+func toolTransformString(ctx context.Context, text string) (interface{}, error) {
+	decodings := map[string]interface{}{}
+	detected := []string{}
+
+	// Base64
+	if b, err := base64.StdEncoding.DecodeString(text); err == nil {
+		if isASCII(string(b)) {
+			decodings["base64"] = string(b)
+			detected = append(detected, "base64")
+		}
+	}
+
+	// URL
+	if un, err := url.QueryUnescape(text); err == nil && un != text {
+		decodings["url"] = un
+		detected = append(detected, "url")
+	}
+
+	// Hex
+	if h, err := hex.DecodeString(text); err == nil {
+		if isASCII(string(h)) {
+			decodings["hex"] = string(h)
+			detected = append(detected, "hex")
+		}
+	}
+
+	// JSON
+	if strings.HasPrefix(strings.TrimSpace(text), "{") || strings.HasPrefix(strings.TrimSpace(text), "[") {
+		var js interface{}
+		if json.Unmarshal([]byte(text), &js) == nil {
+			decodings["json"] = js
+			detected = append(detected, "json")
+		}
+	}
+
+	// Hashes
+	md5Sum := md5.Sum([]byte(text))
+	shaSum := sha256.Sum256([]byte(text))
+
+	return &StringTransform{
+		Original: text,
+		Analysis: StringAnalysis{
+			Length:        len(text),
+			DetectedTypes: detected,
+		},
+		Decodings: decodings,
+		Transformations: map[string]string{
+			"upper":  strings.ToUpper(text),
+			"lower":  strings.ToLower(text),
+			"base64": base64.StdEncoding.EncodeToString([]byte(text)),
+			"url":    url.QueryEscape(text),
+			"hex":    hex.EncodeToString([]byte(text)),
+			"md5":    hex.EncodeToString(md5Sum[:]),
+			"sha256": hex.EncodeToString(shaSum[:]),
+		},
+	}, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+// --- Tool Config ---
+//
+// The file named by --tool-config enables and disables tools, picks the
+// exchange rate files and lists the world_clock zones without a rebuild.
+// It is read at startup and again on SIGHUP; connected clients get
+// notifications/tools/list_changed when the tool set changes.
+
+type ToolConfig struct {
+	Disabled  []string `json:"disabled"`   // tool names hidden from tools/list and refused by tools/call
+	Rates     string   `json:"rates"`      // exchange rate file or directory, relative to the config file
+	RatesBase string   `json:"rates_base"` // currency CSV rates are quoted against, EUR if empty
+	// WorldClock lists the zones convert renders times in, keyed as
+	// written; UTC and Local_Server if empty
+	WorldClock []string `json:"world_clock"`
+}
+
+// applyToolConfig reads path and applies it to r.
+func applyToolConfig(r *Registry, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg ToolConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	var tables []*rateTable
+	if cfg.Rates != "" {
+		rates := cfg.Rates
+		if !filepath.IsAbs(rates) {
+			rates = filepath.Join(filepath.Dir(path), rates)
+		}
+		if tables, err = loadRates(rates, cfg.RatesBase); err != nil {
+			return fmt.Errorf("%s: rates: %v", path, err)
+		}
+	}
+	if err := checkZones(cfg.WorldClock); err != nil {
+		return fmt.Errorf("%s: world_clock: %v", path, err)
+	}
+	if err := r.SetDisabled(cfg.Disabled); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	setRateTables(tables)
+	setWorldClock(cfg.WorldClock)
+	return nil
+}
+
+// watchToolConfig reapplies path to r on every SIGHUP. A bad file is
+// reported and leaves the previous configuration in place.
+func watchToolConfig(r *Registry, path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := applyToolConfig(r, path); err != nil {
+				fmt.Fprintf(os.Stderr, "omni-tool: reloading tool config: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "omni-tool: reloaded tool config %s\n", path)
+		}
+	}()
+}
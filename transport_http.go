@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Streamable HTTP Transport ---
+//
+// Implements the MCP Streamable HTTP transport: clients POST JSON-RPC
+// messages to a single endpoint, may open a GET event stream to receive
+// server-initiated messages, and identify themselves with the session id
+// handed out in the Mcp-Session-Id header of the initialize response.
+
+const (
+	mcpEndpoint     = "/mcp"
+	sessionHeader   = "Mcp-Session-Id"
+	protocolHeader  = "Mcp-Protocol-Version"
+	maxRequestBytes = 1024 * 1024 * 10 // same limit as the stdio scanner
+	sseKeepAlive    = 30 * time.Second
+)
+
+type httpSession struct {
+	*session
+	id       string
+	outbound chan []byte
+	closed   chan struct{}
+	once     sync.Once
+
+	unsubscribe func() // stops tools/list_changed notifications
+}
+
+// enqueue queues a server-initiated message for the session's event stream.
+// Messages are dropped when no stream is draining the queue.
+func (s *httpSession) enqueue(msg []byte) {
+	select {
+	case s.outbound <- msg:
+	case <-s.closed:
+	default:
+	}
+}
+
+func (s *httpSession) close() {
+	s.once.Do(func() { close(s.closed) })
+}
+
+type httpTransport struct {
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+	slots    chan struct{} // bounds concurrent tools/call requests across sessions
+}
+
+func newHTTPTransport(maxInFlight int) *httpTransport {
+	return &httpTransport{
+		sessions: map[string]*httpSession{},
+		slots:    make(chan struct{}, maxInFlight),
+	}
+}
+
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != mcpEndpoint {
+		http.NotFound(w, r)
+		return
+	}
+	if !allowedOrigin(r) {
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleStream(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *httpTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	msgs, batch, errResp := parseMessage(body)
+	if errResp != nil {
+		writeJSON(w, http.StatusBadRequest, errResp)
+		return
+	}
+
+	var req JSONRPCRequest
+	if !batch {
+		var ok bool
+		req, ok, errResp = decodeRequest(msgs[0])
+		if errResp != nil {
+			writeJSON(w, http.StatusBadRequest, errResp)
+			return
+		}
+		if !ok {
+			// Client responses are acknowledged without a body
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if req.Method == "initialize" {
+			sess := t.newSession()
+			resp := handleRequest(r.Context(), sess.session, req)
+			if resp.Error == nil {
+				t.register(sess)
+				w.Header().Set(sessionHeader, sess.id)
+			}
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+	}
+
+	sess, ok := t.lookup(w, r)
+	if !ok {
+		return
+	}
+	// Clients on 2025-06-18 and later repeat the negotiated revision on
+	// every request; older clients omit the header.
+	if v := r.Header.Get(protocolHeader); v != "" && v != sess.protocolVersion() {
+		http.Error(w, protocolHeader+" "+v+" does not match the negotiated revision "+sess.protocolVersion(), http.StatusBadRequest)
+		return
+	}
+
+	if !batch {
+		if flusher, ok := w.(http.Flusher); ok && wantsProgress(req) && strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			t.streamCall(w, flusher, r, sess, req)
+			return
+		}
+		resp := t.dispatch(r.Context(), sess, req)
+		if resp == nil {
+			if req.ID != nil {
+				// The client cancelled this request; the response is dropped
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			// Notifications are acknowledged without a body
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	responses := make([]*JSONRPCResponse, len(msgs))
+	var wg sync.WaitGroup
+	for i, msg := range msgs {
+		req, ok, errResp := decodeRequest(msg)
+		if !ok {
+			responses[i] = errResp
+			continue
+		}
+		if req.Method == "initialize" {
+			responses[i] = errorResponse(req.ID, codeInvalidRequest, "Invalid Request: initialize cannot be batched")
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = t.dispatch(r.Context(), sess, req)
+		}()
+	}
+	wg.Wait()
+
+	if out := compactResponses(responses); len(out) > 0 {
+		writeJSON(w, http.StatusOK, out)
+	} else {
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// dispatch handles one request of an established session. A tool call
+// takes one of the transport's slots while the tool runs.
+func (t *httpTransport) dispatch(ctx context.Context, sess *httpSession, req JSONRPCRequest) *JSONRPCResponse {
+	if req.Method == "tools/call" {
+		ctx = sess.begin(contextWithToolSlots(ctx, t.slots), req.ID)
+		defer sess.end(req.ID)
+	}
+	return handleRequest(ctx, sess.session, req)
+}
+
+// wantsProgress reports whether req is a tools/call with a progressToken.
+func wantsProgress(req JSONRPCRequest) bool {
+	if req.Method != "tools/call" {
+		return false
+	}
+	var params CallToolParams
+	return json.Unmarshal(req.Params, &params) == nil && params.Meta.ProgressToken != nil
+}
+
+// streamCall answers a tools/call that asked for progress with an event
+// stream of its own: the call's progress and log notifications, then its
+// response. The GET stream is left to messages unrelated to a request.
+// Whatever a tool that outlived its deadline sends after the response is
+// dropped.
+func (t *httpTransport) streamCall(w http.ResponseWriter, flusher http.Flusher, r *http.Request, sess *httpSession, req JSONRPCRequest) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var mu sync.Mutex
+	open := true
+	send := func(msg interface{}) {
+		b := encodeMessage(msg)
+		mu.Lock()
+		defer mu.Unlock()
+		if open && b != nil {
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+			flusher.Flush()
+		}
+	}
+	// A cancelled call ends the stream without a response
+	if resp := t.dispatch(contextWithRequestStream(r.Context(), send), sess, req); resp != nil {
+		send(resp)
+	}
+	mu.Lock()
+	open = false
+	mu.Unlock()
+}
+
+// handleStream opens a Server-Sent Events stream carrying messages queued
+// with httpSession.enqueue until the client disconnects or the session ends.
+func (t *httpTransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := t.lookup(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(sessionHeader, sess.id)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-sess.outbound:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+			flusher.Flush()
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-sess.closed:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (t *httpTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess, ok := t.lookup(w, r)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	delete(t.sessions, sess.id)
+	t.mu.Unlock()
+	sess.unsubscribe()
+	sess.close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (t *httpTransport) newSession() *httpSession {
+	b := make([]byte, 16)
+	rand.Read(b)
+	sess := &httpSession{
+		id:       hex.EncodeToString(b),
+		outbound: make(chan []byte, 64),
+		closed:   make(chan struct{}),
+	}
+	sess.session = newSession(func(msg interface{}) {
+		if b := encodeMessage(msg); b != nil {
+			sess.enqueue(b)
+		}
+	})
+	return sess
+}
+
+func (t *httpTransport) register(sess *httpSession) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessions[sess.id] = sess
+	sess.unsubscribe = DefaultRegistry.Subscribe(sess.toolsChanged)
+}
+
+// lookup resolves the request's Mcp-Session-Id header, writing the error
+// response itself when the header is missing or the session is unknown.
+func (t *httpTransport) lookup(w http.ResponseWriter, r *http.Request) (*httpSession, bool) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		http.Error(w, "Missing "+sessionHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+	t.mu.Lock()
+	sess, ok := t.sessions[id]
+	t.mu.Unlock()
+	if !ok {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return nil, false
+	}
+	return sess, true
+}
+
+// allowedOrigin guards against DNS rebinding: browser requests must come
+// from a loopback origin or from the host being served.
+func allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return u.Host == r.Host
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b := encodeMessage(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postMCP sends body to the server's MCP endpoint, with the session id
+// header if id is not empty.
+func postMCP(t *testing.T, srv *httptest.Server, id, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+mcpEndpoint, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id != "" {
+		req.Header.Set(sessionHeader, id)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// initializeHTTP performs the handshake and returns the session id.
+func initializeHTTP(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	resp := postMCP(t, srv, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("initialize: status %d", resp.StatusCode)
+	}
+	id := resp.Header.Get(sessionHeader)
+	if id == "" {
+		t.Fatalf("initialize: no %s header", sessionHeader)
+	}
+	postMCP(t, srv, id, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	return id
+}
+
+func TestHTTPInitializeReturnsSessionID(t *testing.T) {
+	srv := httptest.NewServer(newHTTPTransport(4))
+	defer srv.Close()
+
+	id := initializeHTTP(t, srv)
+	resp := postMCP(t, srv, id, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ping in session: status %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPProtocolVersionHeader(t *testing.T) {
+	srv := httptest.NewServer(newHTTPTransport(4))
+	defer srv.Close()
+
+	id := initializeHTTP(t, srv)
+	for version, want := range map[string]int{"2025-06-18": http.StatusOK, "2025-03-26": http.StatusBadRequest} {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+mcpEndpoint, strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+		req.Header.Set(sessionHeader, id)
+		req.Header.Set(protocolHeader, version)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s %s in a 2025-06-18 session: status %d, want %d", protocolHeader, version, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestHTTPSessionErrors(t *testing.T) {
+	srv := httptest.NewServer(newHTTPTransport(4))
+	defer srv.Close()
+
+	if resp := postMCP(t, srv, "", `{"jsonrpc":"2.0","id":1,"method":"ping"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing session id: status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if resp := postMCP(t, srv, "no-such-session", `{"jsonrpc":"2.0","id":1,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session id: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestHTTPDeleteEndsSession(t *testing.T) {
+	srv := httptest.NewServer(newHTTPTransport(4))
+	defer srv.Close()
+
+	id := initializeHTTP(t, srv)
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+mcpEndpoint, nil)
+	req.Header.Set(sessionHeader, id)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	if resp := postMCP(t, srv, id, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("request after DELETE: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestHTTPBatch(t *testing.T) {
+	srv := httptest.NewServer(newHTTPTransport(4))
+	defer srv.Close()
+
+	id := initializeHTTP(t, srv)
+	resp := postMCP(t, srv, id, `[
+		{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"convert","arguments":{"value":"10","unit":"km"}}},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":"b","method":"ping"}
+	]`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("batch: status %d", resp.StatusCode)
+	}
+	var out []JSONRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("batch: decoding response: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("batch: got %d responses, want 2 (the notification gets none)", len(out))
+	}
+	seen := map[interface{}]bool{}
+	for _, r := range out {
+		if r.Error != nil {
+			t.Errorf("batch: id %v failed: %s", r.ID, r.Error.Message)
+		}
+		seen[r.ID] = true
+	}
+	if !seen["a"] || !seen["b"] {
+		t.Errorf("batch: answered ids %v, want a and b", seen)
+	}
+}
+
+func TestHTTPProgressOnPostStream(t *testing.T) {
+	srv := httptest.NewServer(newHTTPTransport(4))
+	defer srv.Close()
+
+	id := initializeHTTP(t, srv)
+	body := `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"calculate_statistics","arguments":{"numbers":[3,1,2]},"_meta":{"progressToken":"p1"}}}`
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+mcpEndpoint, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(sessionHeader, id)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type %q, want text/event-stream", ct)
+	}
+
+	var methods []string
+	var final map[string]interface{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			t.Fatalf("event is not JSON: %v\n%s", err, data)
+		}
+		if m, ok := msg["method"].(string); ok {
+			if final != nil {
+				t.Errorf("%s after the response", m)
+			}
+			methods = append(methods, m)
+		} else {
+			final = msg
+		}
+	}
+	if len(methods) == 0 || methods[0] != "notifications/progress" {
+		t.Errorf("notifications before the response: %v, want progress first", methods)
+	}
+	if final == nil || final["id"] != float64(7) || final["result"] == nil {
+		t.Errorf("final event %v, want the result of 7", final)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// --- Unit Registry ---
+//
+// Every physical unit is one row of unitTable: the spelling used in
+// conversion output, the other spellings accepted on input, and how to
+// reach the category's base unit. inferCategory, toolConvertUnits and
+// getBaseValue all read this table, so a unit added here is recognized,
+// converted and compared everywhere.
+
+// unitSystemMode says how units of different systems in one category are
+// reported.
+type unitSystemMode int
+
+const (
+	systemsFlat      unitSystemMode = iota // all units in one flat map
+	systemsGrouped                         // one map per system, e.g. metric and imperial
+	systemsExclusive                       // systems do not interconvert; only the input's system is reported
+)
+
+type unitCategory struct {
+	Name    string         `json:"category"`
+	Base    string         `json:"base,omitempty"` // unit with factor 1; per system for systemsExclusive
+	Systems unitSystemMode `json:"-"`
+	Exact   bool           `json:"exact,omitempty"` // converted with big.Rat; see exact.go
+}
+
+// unitDef converts a value v in this unit to the category base as
+// v*Factor + Offset.
+type unitDef struct {
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Category string   `json:"-"`
+	System   string   `json:"system,omitempty"`
+	Factor   float64  `json:"factor"`
+	Offset   float64  `json:"offset,omitempty"`
+	// Binary names the IEC unit that a loose spelling of this SI unit may
+	// mean instead: "mb" or "KB" could be MB or MiB, so they are read as
+	// the binary unit and reported with both interpretations.
+	Binary string `json:"binary,omitempty"`
+	// Decimals marks a token's raw on-chain unit, 10^-Decimals of a token.
+	Decimals int `json:"decimals,omitempty"`
+}
+
+func (u *unitDef) toBase(v float64) float64 { return v*u.Factor + u.Offset }
+
+// fromBase is the inverse of toBase, rounded to conversionDigits
+// significant digits so that 1 mi is 63360 in rather than 63360.00000000001.
+func (u *unitDef) fromBase(b float64) float64 {
+	return roundSig((b-u.Offset)/u.Factor, conversionDigits)
+}
+
+// conversionDigits is the precision of converted values, enough for any
+// real measurement while hiding float64 error from the round trip.
+const conversionDigits = 12
+
+// roundSig rounds x to n significant digits.
+func roundSig(x float64, n int) float64 {
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(x, 'g', n, 64), 64)
+	return r
+}
+
+// unitCategories are the unit categories in lookup order. color has no
+// units of its own; its keywords only route a value to analyze_color.
+var unitCategories = []unitCategory{
+	{Name: "length", Base: "m", Systems: systemsGrouped},
+	{Name: "weight", Base: "kg", Systems: systemsGrouped},
+	{Name: "temperature", Base: "c"},
+	{Name: "digital", Base: "B", Systems: systemsGrouped, Exact: true},
+	{Name: "css", Base: "px"},
+	{Name: "color"},
+	{Name: "crypto", Systems: systemsExclusive, Exact: true},
+	{Name: "duration", Base: "ms"},
+	{Name: "speed", Base: "m/s"},
+	{Name: "area", Base: "sq_m"},
+	{Name: "volume", Base: "ml"},
+}
+
+// colorKeywordUnits are the unit strings that select color analysis.
+var colorKeywordUnits = []string{"hex", "hexadecimal", "rgb", "color", "colour", "hsl"}
+
+var unitTable = append([]unitDef{
+	// Length, base meter. Imperial units use their exact international definitions.
+	{Name: "m", Aliases: []string{"meter", "meters"}, Category: "length", System: "metric", Factor: 1},
+	{Name: "km", Aliases: []string{"kilometer"}, Category: "length", System: "metric", Factor: 1000},
+	{Name: "cm", Aliases: []string{"centimeter"}, Category: "length", System: "metric", Factor: 0.01},
+	{Name: "mm", Aliases: []string{"millimeter"}, Category: "length", System: "metric", Factor: 0.001},
+	{Name: "mi", Aliases: []string{"mile", "miles"}, Category: "length", System: "imperial", Factor: 1609.344},
+	{Name: "ft", Aliases: []string{"foot", "feet"}, Category: "length", System: "imperial", Factor: 0.3048},
+	{Name: "in", Aliases: []string{"inch", "inches"}, Category: "length", System: "imperial", Factor: 0.0254},
+	{Name: "yd", Aliases: []string{"yard", "yards"}, Category: "length", System: "imperial", Factor: 0.9144},
+
+	// Weight, base kilogram
+	{Name: "kg", Aliases: []string{"kilogram"}, Category: "weight", System: "metric", Factor: 1},
+	{Name: "g", Aliases: []string{"gram"}, Category: "weight", System: "metric", Factor: 0.001},
+	{Name: "mg", Aliases: []string{"milligram"}, Category: "weight", System: "metric", Factor: 0.000001},
+	{Name: "lbs", Aliases: []string{"lb", "pound"}, Category: "weight", System: "imperial", Factor: 0.45359237},
+	{Name: "oz", Aliases: []string{"ounce"}, Category: "weight", System: "imperial", Factor: 0.028349523125},
+	{Name: "stone", Category: "weight", System: "imperial", Factor: 6.35029318},
+
+	// Temperature, base Celsius
+	{Name: "c", Aliases: []string{"celsius"}, Category: "temperature", Factor: 1},
+	{Name: "f", Aliases: []string{"fahrenheit"}, Category: "temperature", Factor: 5.0 / 9.0, Offset: -32 * 5.0 / 9.0},
+	{Name: "k", Aliases: []string{"kelvin"}, Category: "temperature", Factor: 1, Offset: -273.15},
+
+	// Digital storage, base byte. SI multiples are powers of 1000, IEC
+	// multiples powers of 1024.
+	{Name: "B", Aliases: []string{"byte", "bytes"}, Category: "digital", System: "si", Factor: 1},
+	{Name: "kB", Aliases: []string{"kilobyte", "kilobytes"}, Category: "digital", System: "si", Factor: 1e3, Binary: "KiB"},
+	{Name: "MB", Aliases: []string{"megabyte", "megabytes"}, Category: "digital", System: "si", Factor: 1e6, Binary: "MiB"},
+	{Name: "GB", Aliases: []string{"gigabyte", "gigabytes"}, Category: "digital", System: "si", Factor: 1e9, Binary: "GiB"},
+	{Name: "TB", Aliases: []string{"terabyte", "terabytes"}, Category: "digital", System: "si", Factor: 1e12, Binary: "TiB"},
+	{Name: "PB", Aliases: []string{"petabyte", "petabytes"}, Category: "digital", System: "si", Factor: 1e15, Binary: "PiB"},
+	{Name: "KiB", Aliases: []string{"kibibyte", "kibibytes"}, Category: "digital", System: "iec", Factor: 1 << 10},
+	{Name: "MiB", Aliases: []string{"mebibyte", "mebibytes"}, Category: "digital", System: "iec", Factor: 1 << 20},
+	{Name: "GiB", Aliases: []string{"gibibyte", "gibibytes"}, Category: "digital", System: "iec", Factor: 1 << 30},
+	{Name: "TiB", Aliases: []string{"tebibyte", "tebibytes"}, Category: "digital", System: "iec", Factor: 1 << 40},
+	{Name: "PiB", Aliases: []string{"pebibyte", "pebibytes"}, Category: "digital", System: "iec", Factor: 1 << 50},
+
+	// CSS, base pixel at a 16px root font size
+	{Name: "px", Aliases: []string{"pixels"}, Category: "css", Factor: 1},
+	{Name: "rem", Category: "css", Factor: 16},
+	{Name: "em", Category: "css", Factor: 16},
+	{Name: "pt", Aliases: []string{"points"}, Category: "css", Factor: 4.0 / 3.0},
+	{Name: "%", Aliases: []string{"percent"}, Category: "css", Factor: 0.16},
+
+	// Crypto, base BTC and ETH; tokens such as USDC follow from cryptoTokens
+	{Name: "btc", Aliases: []string{"bitcoin"}, Category: "crypto", System: "bitcoin", Factor: 1},
+	{Name: "mbtc", Aliases: []string{"millibitcoin"}, Category: "crypto", System: "bitcoin", Factor: 1e-3},
+	{Name: "satoshi", Aliases: []string{"sat", "sats", "satoshis"}, Category: "crypto", System: "bitcoin", Factor: 1e-8},
+	{Name: "eth", Aliases: []string{"ether"}, Category: "crypto", System: "ethereum", Factor: 1},
+	{Name: "gwei", Category: "crypto", System: "ethereum", Factor: 1e-9},
+	{Name: "wei", Category: "crypto", System: "ethereum", Factor: 1e-18},
+
+	// Duration, base millisecond
+	{Name: "ms", Aliases: []string{"millisecond", "milliseconds"}, Category: "duration", Factor: 1},
+	{Name: "seconds", Aliases: []string{"s", "sec", "second"}, Category: "duration", Factor: 1000},
+	{Name: "minutes", Aliases: []string{"min", "minute"}, Category: "duration", Factor: 60 * 1000},
+	{Name: "hours", Aliases: []string{"h", "hr", "hour"}, Category: "duration", Factor: 60 * 60 * 1000},
+	{Name: "days", Aliases: []string{"d", "day"}, Category: "duration", Factor: 24 * 60 * 60 * 1000},
+	{Name: "weeks", Aliases: []string{"w", "wk", "week"}, Category: "duration", Factor: 7 * 24 * 60 * 60 * 1000},
+
+	// Speed, base meters per second
+	{Name: "m/s", Aliases: []string{"mps"}, Category: "speed", Factor: 1},
+	{Name: "km/h", Aliases: []string{"kmh", "kph"}, Category: "speed", Factor: 1 / 3.6},
+	{Name: "mph", Category: "speed", Factor: 0.44704},
+	{Name: "ft/s", Aliases: []string{"fps"}, Category: "speed", Factor: 0.3048},
+	{Name: "knots", Aliases: []string{"knot", "kn"}, Category: "speed", Factor: 1852.0 / 3600.0},
+
+	// Area, base square meter
+	{Name: "sq_m", Aliases: []string{"sqm", "sq m"}, Category: "area", Factor: 1},
+	{Name: "sq_ft", Aliases: []string{"sqft", "sq ft"}, Category: "area", Factor: 0.09290304},
+	{Name: "sq_km", Aliases: []string{"sqkm", "sq km"}, Category: "area", Factor: 1_000_000},
+	{Name: "sq_mi", Aliases: []string{"sqmi", "sq mi"}, Category: "area", Factor: 2_589_988.110336},
+	{Name: "acres", Aliases: []string{"acre"}, Category: "area", Factor: 4046.8564224},
+	{Name: "hectares", Aliases: []string{"hectare", "ha"}, Category: "area", Factor: 10_000},
+
+	// Volume, base milliliter, US customary units
+	{Name: "ml", Aliases: []string{"milliliter", "milliliters"}, Category: "volume", Factor: 1},
+	{Name: "liters", Aliases: []string{"l", "liter", "litre", "litres"}, Category: "volume", Factor: 1000},
+	{Name: "gallons", Aliases: []string{"gal", "gallon"}, Category: "volume", Factor: 3785.411784},
+	{Name: "fl_oz", Aliases: []string{"floz", "fl oz"}, Category: "volume", Factor: 29.5735295625},
+	{Name: "cups", Aliases: []string{"cup"}, Category: "volume", Factor: 236.5882365},
+	{Name: "pints", Aliases: []string{"pint"}, Category: "volume", Factor: 473.176473},
+	{Name: "quarts", Aliases: []string{"qt", "quart"}, Category: "volume", Factor: 946.352946},
+}, tokenUnits()...)
+
+// unitIndex maps every lowercase spelling to its unit.
+var unitIndex = buildUnitIndex()
+
+func buildUnitIndex() map[string]*unitDef {
+	index := map[string]*unitDef{}
+	for i := range unitTable {
+		u := &unitTable[i]
+		for _, name := range append([]string{u.Name}, u.Aliases...) {
+			name = strings.ToLower(name)
+			if _, dup := index[name]; dup {
+				panic(fmt.Sprintf("unit spelling %q defined twice", name))
+			}
+			index[name] = u
+		}
+	}
+	return index
+}
+
+// lookupUnit finds a unit by any of its spellings, ignoring case and
+// surrounding space. An ambiguous spelling resolves to its binary unit;
+// see resolveUnit.
+func lookupUnit(s string) (*unitDef, bool) {
+	u, _, ok := resolveUnit(s)
+	return u, ok
+}
+
+// resolveUnit finds the unit s names. When s is a loose spelling of an SI
+// unit that has a Binary counterpart, such as "mb" or "KB", u is the
+// binary unit and alt the decimal one; alt is nil for exact spellings.
+func resolveUnit(s string) (u, alt *unitDef, ok bool) {
+	s = strings.TrimSpace(s)
+	u, ok = unitIndex[strings.ToLower(s)]
+	if !ok || u.Binary == "" || u.spelledExactly(s) {
+		return u, nil, ok
+	}
+	return unitIndex[strings.ToLower(u.Binary)], u, true
+}
+
+func (u *unitDef) spelledExactly(s string) bool {
+	return s == u.Name || containsString(u.Aliases, s)
+}
+
+// categoryUnits returns the units of category in table order.
+func categoryUnits(category string) []*unitDef {
+	var units []*unitDef
+	for i := range unitTable {
+		if unitTable[i].Category == category {
+			units = append(units, &unitTable[i])
+		}
+	}
+	return units
+}
+
+// categorySpellings lists every input spelling of category.
+func categorySpellings(category string) []string {
+	if category == "color" {
+		return colorKeywordUnits
+	}
+	var names []string
+	for _, u := range categoryUnits(category) {
+		names = append(names, u.Name)
+		names = append(names, u.Aliases...)
+	}
+	return names
+}
+
+func findCategory(name string) (unitCategory, bool) {
+	for _, c := range unitCategories {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return unitCategory{}, false
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// approxEqual compares to the precision fromBase rounds to.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// roundTripValues include negatives and zero for the temperature offsets.
+var roundTripValues = []float64{-273.15, -40, -1.5, 0, 1, 3.7, 98.6, 1234.5, 1e9}
+
+func TestUnitTableRoundTrip(t *testing.T) {
+	for i := range unitTable {
+		u := &unitTable[i]
+		for _, x := range roundTripValues {
+			if got := u.fromBase(u.toBase(x)); !approxEqual(got, x) {
+				t.Errorf("%s (%s): fromBase(toBase(%v)) = %v", u.Name, u.Category, x, got)
+			}
+		}
+	}
+}
+
+func TestUnitTableRoundTripThroughCategory(t *testing.T) {
+	// x in u, converted to every other unit of its category and back
+	for i := range unitTable {
+		u := &unitTable[i]
+		for j := range unitTable {
+			v := &unitTable[j]
+			if v.Category != u.Category {
+				continue
+			}
+			for _, x := range roundTripValues {
+				there := v.fromBase(u.toBase(x))
+				if back := u.fromBase(v.toBase(there)); !approxEqual(back, x) {
+					t.Errorf("%v %s -> %v %s -> %v %s", x, u.Name, there, v.Name, back, u.Name)
+				}
+			}
+		}
+	}
+}
+
+func TestFuelEconomyRoundTrip(t *testing.T) {
+	// L/100km is the inverse dimension of mpg and km/L
+	_, units, ok := findDerivedQuantity(mustParseUnitExpr(t, "mpg").Dim)
+	if !ok {
+		t.Fatal("mpg has no derived quantity")
+	}
+	for _, from := range units {
+		qf := mustParseUnitExpr(t, from)
+		for _, to := range units {
+			qt := mustParseUnitExpr(t, to)
+			for _, x := range []float64{1, 4.7, 23.5, 100} {
+				there, ok := convertQuantity(x, qf, qt)
+				if !ok {
+					t.Fatalf("%s -> %s does not convert", from, to)
+				}
+				if back, _ := convertQuantity(there, qt, qf); !approxEqual(back, x) {
+					t.Errorf("%v %s -> %v %s -> %v %s", x, from, there, to, back, from)
+				}
+			}
+		}
+	}
+	// 100 / 23.5214583 mpg is about 10 L/100km
+	v, _ := convertQuantity(23.5214583, mustParseUnitExpr(t, "mpg"), mustParseUnitExpr(t, "L/100km"))
+	if math.Abs(v-10) > 1e-6 {
+		t.Errorf("23.5214583 mpg = %v L/100km, want 10", v)
+	}
+}
+
+func mustParseUnitExpr(t *testing.T, s string) quantity {
+	t.Helper()
+	q, err := parseUnitExpr(s)
+	if err != nil {
+		t.Fatalf("parseUnitExpr(%q): %v", s, err)
+	}
+	return q
+}